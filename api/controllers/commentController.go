@@ -1,124 +1,146 @@
 package controllers
 
 import (
-	"net/http"
+	"errors"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/Tokenzrey/FPPBKKGOLANG/api/middleware"
-	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
+	format_errors "github.com/Tokenzrey/FPPBKKGOLANG/internal/format-errors"
 	"github.com/Tokenzrey/FPPBKKGOLANG/internal/helpers"
 	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/repository"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/service"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/validations"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 )
 
-func PostComment(c *gin.Context) {
+// CommentController handles posting, listing and deleting comments.
+type CommentController struct {
+	comments  *service.CommentService
+	validator *validations.Validator
+}
+
+// NewCommentController creates a CommentController.
+func NewCommentController(comments *service.CommentService, validator *validations.Validator) *CommentController {
+	return &CommentController{comments: comments, validator: validator}
+}
+
+func (ctl *CommentController) PostComment(c *gin.Context) error {
 	// Extract user ID from the token
-	userID, err := middleware.GetUserIDFromToken(c)
-	if err != nil {
+	userID, ok := middleware.UserID(c)
+	if !ok {
 		// Respond with unauthorized if token is missing, invalid, or expired
-		helpers.ErrorResponse(c, http.StatusUnauthorized, "Token missing, invalid, or expired")
-		return
+		return format_errors.Unauthorized("Token missing, invalid, or expired")
 	}
 
 	// Bind Blog ID from request
 	var inputComment struct {
-		Comment string `json:"comment" binding:"required,min=5,max=250"`
-		BlogID  uint   `json:"blog_id" binding:"required"`
+		Comment string `json:"comment" validate:"required,min=5,max=250"`
+		BlogID  uint   `json:"blog_id" validate:"required"`
 	}
 
 	// Bind and validate JSON input
-	if err := c.ShouldBindJSON(&inputComment); err != nil {
-		if errs, ok := err.(validator.ValidationErrors); ok {
-			// Gabungkan semua pesan error dalam satu string
-			var errorMessage string
-			for _, e := range errs {
-				errorMessage += e.Field() + ": " + e.ActualTag() + "; "
-			}
-			// Trim karakter terakhir
-			errorMessage = strings.TrimSpace(errorMessage)
-
-			helpers.ErrorResponse(c, http.StatusUnprocessableEntity, "Validation failed: "+errorMessage)
-			return
-		}
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Invalid input format")
-		return
+	if err := bindJSON(c, ctl.validator, &inputComment); err != nil {
+		return err
 	}
 
-	// Check if blog exists
-	var blog models.Blog
-	if err := initializers.DB.First(&blog, inputComment.BlogID).Error; err != nil {
-		if inputComment.BlogID == 0 {
-			helpers.ErrorResponse(c, http.StatusNotFound, "Blog not found")
-			return
+	// Save the comment and count it on the blog
+	comment, err := ctl.comments.Post(c.Request.Context(), userID, inputComment.BlogID, inputComment.Comment)
+	if err != nil {
+		if errors.Is(err, service.ErrBlogNotFound) {
+			return format_errors.NotFound("Blog not found")
+		}
+		if errors.Is(err, repository.ErrConflict) {
+			return format_errors.From(err)
 		}
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Error checking blog")
-		return
+		return format_errors.Internal("Error Commenting on Post", err)
 	}
+	helpers.Created(c, gin.H{"id": comment.ID, "blog_id": comment.BlogID, "posted": true}, "Comment Posted!")
+	return nil
+}
 
-	newComment := models.Comment{
-		Comment: inputComment.Comment,
-		UserID:  uint(userID),
-		BlogID:  inputComment.BlogID,
+func (ctl *CommentController) DeleteComment(c *gin.Context) error {
+	// Extract user ID from the token
+	userID, ok := middleware.UserID(c)
+	if !ok {
+		// Respond with unauthorized if token is missing, invalid, or expired
+		return format_errors.Unauthorized("Token missing, invalid, or expired")
 	}
 
-	if err := initializers.DB.Create(&newComment).Error; err != nil {
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Error Commenting on Post")
-		return
+	commentID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return format_errors.NotFound("Comment not found")
 	}
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Comment Posted!",
-		"posted":  true,
-	})
+
+	// Delete the comment if the user wrote it, and uncount it on the blog
+	comment, err := ctl.comments.Delete(c.Request.Context(), userID, uint(commentID))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCommentNotFound):
+			return format_errors.NotFound("Comment not found")
+		case errors.Is(err, service.ErrForbidden):
+			return format_errors.Forbidden("You are not authorized to delete this comment")
+		case errors.Is(err, repository.ErrConflict):
+			return format_errors.From(err)
+		default:
+			return format_errors.Internal("Error deleting comment", err)
+		}
+	}
+
+	helpers.SuccessResponse(c, gin.H{"id": comment.ID}, "Comment deleted successfully")
+	return nil
 }
 
-func ShowComments(c *gin.Context) {
+func (ctl *CommentController) ShowComments(c *gin.Context) error {
 	// Get blog_id from path parameter
 	blogIDStr := c.Param("blog_id")
 	blogID, err := strconv.ParseUint(blogIDStr, 10, 64)
 	if err != nil {
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Invalid blog_id")
-		return
-	}
-
-	// Check if blog exists
-	var blog models.Blog
-	if err := initializers.DB.First(&blog, blogID).Error; err != nil {
-		helpers.ErrorResponse(c, http.StatusNotFound, "Blog not found")
-		return
+		return format_errors.BadRequest("Invalid blog_id")
 	}
 
-	// Prepare a slice to store comments with user details
-	var comments []struct {
-		ID        uint      `json:"id"`
-		Comment   string    `json:"comment"`
-		CreatedAt time.Time `json:"created_at"`
-		User_ID   uint      `json:"user_id"`
-		User_Name string    `json:"user_name"`
-	}
-
-	// Fetch comments
-	if err := initializers.DB.Preload("users").Model(&models.Comment{}).Select("comments.id, comments.comment, comments.created_at, users.id as user_id, users.name as user_name").Joins("LEFT JOIN users ON comments.user_id =users.id").Where("comments.blog_id = ?", blogID).Order("comments.created_at DESC").Scan(&comments).Error; err != nil {
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Error Loading Comments")
-		return
+	// Fetch the comments of the blog, newest first
+	found, err := ctl.comments.List(c.Request.Context(), uint(blogID))
+	if err != nil {
+		if errors.Is(err, service.ErrBlogNotFound) {
+			return format_errors.NotFound("Blog not found")
+		}
+		return format_errors.Internal("Error Loading Comments", err)
 	}
 
-	// If there's no comment
-	if comments == nil {
-		comments = []struct {
-			ID        uint      `json:"id"`
-			Comment   string    `json:"comment"`
-			CreatedAt time.Time `json:"created_at"`
-			User_ID   uint      `json:"user_id"`
-			User_Name string    `json:"user_name"`
-		}{}
-	}
+	// Return each comment with its author's name
+	comments := commentResponses(found)
 
-	c.JSON(http.StatusOK, gin.H{
+	helpers.SuccessResponse(c, gin.H{
 		"blog_id":  blogID,
 		"comments": comments,
 		"count":    len(comments),
-	})
+	}, "Comments retrieved successfully")
+	return nil
+}
+
+// commentResponse is a comment as clients see it, with the ID and name of
+// its author but none of their other details.
+type commentResponse struct {
+	ID        uint      `json:"id"`
+	Comment   string    `json:"comment"`
+	CreatedAt time.Time `json:"created_at"`
+	User_ID   uint      `json:"user_id"`
+	User_Name string    `json:"user_name"`
+}
+
+// commentResponses maps comments, loaded with their authors, to responses.
+func commentResponses(found []models.Comment) []commentResponse {
+	comments := make([]commentResponse, 0, len(found))
+	for _, comment := range found {
+		comments = append(comments, commentResponse{
+			ID:        comment.ID,
+			Comment:   comment.Comment,
+			CreatedAt: comment.CreatedAt,
+			User_ID:   comment.UserID,
+			User_Name: comment.User.Name,
+		})
+	}
+	return comments
 }
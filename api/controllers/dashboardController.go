@@ -1,23 +1,35 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/Tokenzrey/FPPBKKGOLANG/api/middleware"
-	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
+	format_errors "github.com/Tokenzrey/FPPBKKGOLANG/internal/format-errors"
 	"github.com/Tokenzrey/FPPBKKGOLANG/internal/helpers"
-	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
-	"github.com/Tokenzrey/FPPBKKGOLANG/internal/pagination"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/imaging"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/metrics"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/repository"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/search"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/service"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/suggest"
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
+// BlogController handles listing, searching, publishing and deleting blogs.
+type BlogController struct {
+	blogs *service.BlogService
+}
+
+// NewBlogController creates a BlogController.
+func NewBlogController(blogs *service.BlogService) *BlogController {
+	return &BlogController{blogs: blogs}
+}
+
 // GetBlogs retrieves a paginated list of blogs sorted by likes or comments
 //
 // @Summary Get blog list
@@ -28,72 +40,50 @@ import (
 // @Param page query int false "Page number" default(1)
 // @Param perPage query int false "Items per page" default(10)
 // @Param sort query string false "Sort by 'likes' or 'comments'" Enums(likes, comments)
-// @Success 200 {object} object{status=string,data=object{blogs=[]models.Blog},message=string} "Blogs retrieved successfully"
-// @Failure 400 {object} object{status=string,message=string} "Invalid sort parameter"
+// @Success 200 {object} object{status=string,data=[]models.Blog,message=string,meta=helpers.Meta} "Blogs retrieved successfully"
+// @Failure 400 {object} object{status=string,message=string,code=string} "Invalid sort parameter"
 // @Failure 500 {object} object{status=string,message=string} "Internal server error"
 // @Router /blogs [get]
-func GetBlogs(c *gin.Context) {
+func (ctl *BlogController) GetBlogs(c *gin.Context) error {
 	// Get query parameters for pagination and sorting
 	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
 	if err != nil || page <= 0 {
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Invalid page parameter")
-		return
+		return format_errors.BadRequest("Invalid page parameter")
 	}
 
 	perPage, err := strconv.Atoi(c.DefaultQuery("perPage", "10"))
 	if err != nil || perPage <= 0 || perPage > 100 {
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Invalid perPage parameter")
-		return
+		return format_errors.BadRequest("Invalid perPage parameter")
 	}
 
 	sort := c.DefaultQuery("sort", "") // Default: no sorting
 
 	// Validate sort parameter
 	if sort != "" && sort != "likes" && sort != "comments" {
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Invalid sort parameter. Must be 'likes' or 'comments'")
-		return
+		return format_errors.BadRequest("Invalid sort parameter. Must be 'likes' or 'comments'")
 	}
 
-	// Define the output structure
-	var blogs []models.Blog
-
-	// Apply sorting logic based on the 'sort' query parameter
-	rawFunc := func(db *gorm.DB) *gorm.DB {
-	query := db.Preload("User") // Preload user details
-
-	switch sort {
-		case "likes":
-			// Subquery for sorting by likes
-			return query.Select("blogs.*, (SELECT COUNT(*) FROM likes WHERE likes.blog_id = blogs.id) as like_count").
-				Order("like_count DESC")
-		case "comments":
-			// Subquery for sorting by comments
-			return query.Select("blogs.*, (SELECT COUNT(*) FROM comments WHERE comments.blog_id = blogs.id) as comment_count").
-				Order("comment_count DESC")
-		default:
-			// Default sorting by blog creation date
-			return query.Order("blogs.created_at DESC")
-	}
-}
-
-	// Perform pagination and query execution
-	result, err := pagination.Paginate(initializers.DB, page, perPage, rawFunc, &blogs)
+	// Load the page, sorted by the stored counters when requested
+	result, err := ctl.blogs.List(c.Request.Context(), repository.ListOptions{
+		Page:    page,
+		PerPage: perPage,
+		Sort:    sort,
+	})
 	if err != nil {
-		// Log the error for debugging
-		fmt.Printf("Error executing query: %v\n", err)
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve blogs")
-		return
+		return format_errors.Internal("Failed to retrieve blogs", err)
 	}
 
-	// Return paginated blog list
-	helpers.SuccessResponse(c, result, "Blogs retrieved successfully")
+	// Return the blogs of the page with its position as meta
+	helpers.Paginated(c, result, "Blogs retrieved successfully")
+	return nil
 }
 
 
 // SearchBlogs retrieves a paginated list of blogs filtered by search query
 //
 // @Summary Search blogs
-// @Description Searches blogs by username, judul, or content with pagination.
+// @Description Full-text searches blogs by username, judul, or content, ordered by relevance with title matches boosted.
+// @Description Supports quoted phrases ("exact words") and prefix queries (word*); each hit carries a score and highlighted snippets.
 // @Tags Blog
 // @Accept json
 // @Produce json
@@ -101,80 +91,72 @@ func GetBlogs(c *gin.Context) {
 // @Param perPage query int false "Items per page" default(10)
 // @Param search query string true "Search keyword"
 // @Param filter query string false "Filter by 'username', 'judul', 'content' or 'all'" Enums(username, judul, content, all) default(all)
-// @Success 200 {object} object{status=string,data=object{blogs=[]models.Blog},message=string} "Blogs retrieved successfully"
+// @Success 200 {object} object{status=string,data=[]search.Hit,message=string,meta=helpers.Meta} "Blogs retrieved successfully"
 // @Failure 400 {object} object{status=string,message=string} "Invalid search filter"
 // @Failure 500 {object} object{status=string,message=string} "Internal server error"
 // @Router /blogs/search [get]
-func SearchBlogs(c *gin.Context) {
+func (ctl *BlogController) SearchBlogs(c *gin.Context) error {
 	// Get query parameters for pagination and search
 	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
 	if err != nil || page <= 0 {
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Invalid page parameter")
-		return
+		return format_errors.BadRequest("Invalid page parameter")
 	}
 
 	perPage, err := strconv.Atoi(c.DefaultQuery("perPage", "10"))
 	if err != nil || perPage <= 0 || perPage > 100 {
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Invalid perPage parameter")
-		return
+		return format_errors.BadRequest("Invalid perPage parameter")
 	}
 
-	search := strings.TrimSpace(c.Query("search")) // Trim whitespace to handle empty input
+	keyword := strings.TrimSpace(c.Query("search")) // Trim whitespace to handle empty input
 	filter := c.DefaultQuery("filter", "all")
 
 	// Validate filter parameter
-	validFilters := map[string]bool{"username": true, "judul": true, "content": true, "all": true}
-	if _, isValid := validFilters[filter]; !isValid {
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Invalid filter parameter. Must be 'username', 'judul', 'content', or 'all'")
-		return
-	}
-
-	// Define the output structure
-	var blogs []models.Blog
-
-	// Apply search logic based on the 'filter' query parameter
-	rawFunc := func(db *gorm.DB) *gorm.DB {
-		// Base query with user details preloaded
-		query := db.Preload("User").Order("blogs.created_at DESC")
-
-		// Add search conditions if search parameter is not empty
-		if search != "" {
-			switch filter {
-			case "username":
-				// Search by username
-				query = query.Joins("JOIN users ON users.id = blogs.user_id").
-					Where("users.name LIKE ?", "%"+search+"%")
-			case "judul":
-				// Search by blog title
-				query = query.Where("blogs.judul LIKE ?", "%"+search+"%")
-			case "content":
-				// Search by blog content
-				query = query.Where("blogs.content LIKE ?", "%"+search+"%")
-			default:
-				// Search in all fields
-				query = query.Joins("LEFT JOIN users ON users.id = blogs.user_id").
-					Where("users.name LIKE ? OR blogs.judul LIKE ? OR blogs.content LIKE ?", "%"+search+"%", "%"+search+"%", "%"+search+"%")
-			}
-		}
-
-		// Return the modified query
-		return query
+	if !search.Filters[filter] {
+		return format_errors.BadRequest("Invalid filter parameter. Must be 'username', 'judul', 'content', or 'all'")
 	}
 
-	// Perform pagination and query execution
-	result, err := pagination.Paginate(initializers.DB, page, perPage, rawFunc, &blogs)
+	// Run the full-text search ranked by relevance
+	result, err := ctl.blogs.Search(c.Request.Context(), search.Request{
+		Query:   keyword,
+		Filter:  filter,
+		Page:    page,
+		PerPage: perPage,
+	})
 	if err != nil {
-		// Log the error for debugging
-		fmt.Printf("Error executing query: %v\n", err)
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve blogs")
-		return
+		return format_errors.Internal("Failed to retrieve blogs", err)
 	}
 
-	// Return paginated blog search results
-	helpers.SuccessResponse(c, result, "Blogs retrieved successfully")
+	// Return the hits of the page with its position as meta
+	helpers.Paginated(c, result, "Blogs retrieved successfully")
+	return nil
 }
 
 
+// SuggestBlogs returns title and author completions for a search-box prefix
+//
+// @Summary Suggest search completions
+// @Description Returns blog titles and author names starting with the query (tolerating small typos), weighted by like counts.
+// @Tags Blog
+// @Accept json
+// @Produce json
+// @Param q query string true "Prefix typed so far"
+// @Param limit query int false "Maximum completions per kind" default(5)
+// @Success 200 {object} object{status=string,data=suggest.Result,message=string} "Suggestions retrieved successfully"
+// @Failure 400 {object} object{status=string,message=string} "Invalid limit parameter"
+// @Router /blogs/suggest [get]
+func (ctl *BlogController) SuggestBlogs(c *gin.Context) error {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if err != nil || limit <= 0 || limit > suggest.MaxLimit {
+		return format_errors.BadRequest(fmt.Sprintf("Invalid limit parameter. Must be between 1 and %d", suggest.MaxLimit))
+	}
+
+	// Served from memory, so this is cheap enough to call on every keystroke
+	result := ctl.blogs.Suggest(c.Query("q"), limit)
+
+	helpers.SuccessResponse(c, result, "Suggestions retrieved successfully")
+	return nil
+}
+
 // func PostBlog(c *gin.Context) {
 // 	// Define user input structure
 // 	userID, err := middleware.GetUserIDFromToken(c)
@@ -245,53 +227,43 @@ func SearchBlogs(c *gin.Context) {
 // 	helpers.SuccessResponse(c, userResponse, "Blog created successfully")
 // }
 
-func DeleteBlog(c *gin.Context) {
+func (ctl *BlogController) DeleteBlog(c *gin.Context) error {
 	// Get the blog ID from the URL parameter
-	blogID := c.Param("id")
+	blogID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return format_errors.NotFound("Blog not found")
+	}
 
 	// Get the user ID from the token
-	userID, err := middleware.GetUserIDFromToken(c)
-	if err != nil {
+	userID, ok := middleware.UserID(c)
+	if !ok {
 		// Respond with unauthorized if token is missing, invalid, or expired
-		helpers.ErrorResponse(c, http.StatusUnauthorized, "Token missing, invalid, or expired")
-		return
+		return format_errors.Unauthorized("Token missing, invalid, or expired")
 	}
 
-	// Find the blog in the database
-	var blog models.Blog
-	if err := initializers.DB.First(&blog, blogID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// Blog not found
-			helpers.ErrorResponse(c, http.StatusNotFound, "Blog not found")
-			return
+	// Delete the blog if it belongs to the current user, then its unused thumbnail
+	blog, err := ctl.blogs.Delete(c.Request.Context(), userID, uint(blogID))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrBlogNotFound):
+			return format_errors.NotFound("Blog not found")
+		case errors.Is(err, service.ErrForbidden):
+			return format_errors.Forbidden("You are not authorized to delete this blog")
+		default:
+			return format_errors.Internal("Failed to delete blog", err)
 		}
-		// General error finding the blog
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Failed to find blog")
-		return
-	}
-
-	// Check if the blog belongs to the current user
-	if blog.UserID != uint(userID) {
-		helpers.ErrorResponse(c, http.StatusForbidden, "You are not authorized to delete this blog")
-		return
-	}
-
-	// Delete the blog
-	if err := initializers.DB.Delete(&blog).Error; err != nil {
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete blog")
-		return
 	}
 
 	// Respond with success
 	helpers.SuccessResponse(c, gin.H{"id": blog.ID}, "Blog deleted successfully")
+	return nil
 }
 
-func PostBlog(c *gin.Context) {
+func (ctl *BlogController) PostBlog(c *gin.Context) error {
 	// Get the user ID from the token
-	userID, err := middleware.GetUserIDFromToken(c)
-	if err != nil {
-		helpers.ErrorResponse(c, http.StatusUnauthorized, "Token missing, invalid, or expired")
-		return
+	userID, ok := middleware.UserID(c)
+	if !ok {
+		return format_errors.Unauthorized("Token missing, invalid, or expired")
 	}
 
 	// Parse form data
@@ -300,151 +272,110 @@ func PostBlog(c *gin.Context) {
 
 	// Validate input fields
 	if judul == "" || content == "" {
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Judul and content are required")
-		return
+		return format_errors.BadRequest("Judul and content are required")
 	}
 
 	// Check if the form contains an image file
 	file, err := c.FormFile("thumbnail")
 	if err != nil {
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Thumbnail image is required")
-		return
+		return format_errors.BadRequest("Thumbnail image is required")
 	}
 
 	// Validate the file size (max 3MB)
+	metrics.UploadSize.Observe(float64(file.Size))
 	const maxFileSize = 3 * 1024 * 1024
 	if file.Size > maxFileSize {
-		helpers.ErrorResponse(c, http.StatusBadRequest, "File size exceeds the 3MB limit")
-		return
+		return format_errors.New(http.StatusBadRequest, helpers.CodeFileTooLarge, "File size exceeds the 3MB limit")
 	}
 
-	// Open the uploaded file for mime type validation
+	// Open the uploaded file for validation
 	src, err := file.Open()
 	if err != nil {
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Failed to open the file")
-		return
+		return format_errors.Internal("Failed to open the file", err)
 	}
 	defer src.Close()
 
-	// Detect the mime type of the file
-	buffer := make([]byte, 512) // Read the first 512 bytes for mime detection
-	if _, err := src.Read(buffer); err != nil {
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Failed to read the file")
-		return
-	}
-	mimeType := http.DetectContentType(buffer)
-
-	// Validate the mime type
-	allowedMimeTypes := map[string]bool{
-		"image/jpeg": true,
-		"image/png":  true,
-	}
-	if !allowedMimeTypes[mimeType] {
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Invalid file type. Only JPG, JPEG, and PNG are allowed")
-		return
-	}
-
-	// Create an upload directory if it doesn't exist
-	uploadDir := "./uploads"
-	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Failed to create upload directory")
-		return
-	}
-
-	// Save the uploaded file with a unique filename in the uploads directory
-	ext := filepath.Ext(file.Filename)
-	fileName := fmt.Sprintf("%d%s", time.Now().UnixNano(), ext) // Unique file name
-	filePath := filepath.Join(uploadDir, fileName)             // Full file path in uploads directory
-
-	if err := c.SaveUploadedFile(file, filePath); err != nil {
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Failed to save the file")
-		return
-	}
-
-	// Save blog data to the database
-	blog := models.Blog{
-		Judul:     judul,
-		Content:   content,
-		Thumbnail: fileName, // Save only the file name in the database
-		UserID:    uint(userID),
+	// Read the whole file; the size has already been limited above
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return format_errors.Internal("Failed to read the file", err)
 	}
 
-	if err := initializers.DB.Create(&blog).Error; err != nil {
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Failed to create blog")
-		return
+	// Process and store the image, then save the blog and index it
+	blog, err := ctl.blogs.Create(c.Request.Context(), userID, judul, content, data)
+	if err != nil {
+		switch {
+		case errors.Is(err, imaging.ErrUnsupportedFormat):
+			return format_errors.New(http.StatusBadRequest, helpers.CodeInvalidImage, "Invalid file type. Only JPG, JPEG, PNG, WebP and GIF are allowed")
+		case errors.Is(err, imaging.ErrTooLarge):
+			return format_errors.New(http.StatusBadRequest, helpers.CodeInvalidImage, fmt.Sprintf("Image dimensions exceed the %dx%d pixel limit", imaging.MaxDimension, imaging.MaxDimension))
+		case errors.Is(err, imaging.ErrInvalidImage):
+			return format_errors.New(http.StatusBadRequest, helpers.CodeInvalidImage, "Invalid or corrupted image file")
+		case errors.Is(err, service.ErrProcessThumbnail):
+			return format_errors.Internal("Failed to process the image", err)
+		case errors.Is(err, service.ErrStoreThumbnail):
+			return format_errors.Internal("Failed to save the file", err)
+		default:
+			return format_errors.Internal("Failed to create blog", err)
+		}
 	}
 
 	// Respond with success
 	helpers.SuccessResponse(c, gin.H{
 		"message": "Blog created successfully",
 		"blog": gin.H{
-			"id":        blog.ID,
-			"judul":     blog.Judul,
-			"content":   blog.Content,
-			"thumbnail": fmt.Sprintf("/uploads/%s", fileName), // Publicly accessible path
+			"id":         blog.ID,
+			"judul":      blog.Judul,
+			"content":    blog.Content,
+			"thumbnail":  ctl.blogs.ThumbnailURL(blog.Thumbnail), // Publicly accessible path
+			"thumbnails": imaging.SrcSet(blog.Thumbnail, ctl.blogs.ThumbnailURL),
 		},
 	}, "Blog created successfully")
+	return nil
 }
 
 // GetBlogByID retrieves a blog post by its ID, including likes and comments.
-func GetBlog(c *gin.Context) {
+func (ctl *BlogController) GetBlog(c *gin.Context) error {
 	// Get the Blog ID from the request parameters
-	blogID := c.Param("id")
-	if blogID == "" {
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Blog ID is required")
-		return
-	}
-
-	// Retrieve the authenticated user ID from the token
-	userID, err := middleware.GetUserIDFromToken(c)
+	blogID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		helpers.ErrorResponse(c, http.StatusUnauthorized, "Token missing, invalid, or expired")
-		return
+		return format_errors.BadRequest("Blog ID is required")
 	}
 
-	// Retrieve the blog details
-	var blog models.Blog
-	if err := initializers.DB.Preload("User").First(&blog, blogID).Error; err != nil {
-		helpers.ErrorResponse(c, http.StatusNotFound, "Blog not found")
-		return
-	}
-
-	// Retrieve the number of likes for the blog
-	var likesCount int64
-	if err := initializers.DB.Model(&models.Like{}).Where("blog_id = ?", blogID).Count(&likesCount).Error; err != nil {
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Error counting likes")
-		return
-	}
-
-	// Check if the user has already liked the blog
-	var hasLiked bool
-	if err := initializers.DB.Model(&models.Like{}).Where("user_id = ? AND blog_id = ?", userID, blogID).First(&models.Like{}).Error; err == nil {
-		hasLiked = true
+	// Retrieve the authenticated user ID from the token
+	userID, ok := middleware.UserID(c)
+	if !ok {
+		return format_errors.Unauthorized("Token missing, invalid, or expired")
 	}
 
-	// Retrieve comments for the blog
-	var comments []models.Comment
-	if err := initializers.DB.Where("blog_id = ?", blogID).Find(&comments).Error; err != nil {
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Error fetching comments")
-		return
+	// Retrieve the blog, whether the user has liked it, and its comments
+	detail, err := ctl.blogs.Get(c.Request.Context(), uint(blogID), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrBlogNotFound) {
+			return format_errors.NotFound("Blog not found")
+		}
+		return format_errors.Internal("Error fetching comments", err)
 	}
+	blog := detail.Blog
 
 	// Construct the response payload
 	response := gin.H{
-		"id":        blog.ID,
-		"title":     blog.Judul,
-		"content":   blog.Content,
-		"thumbnail": blog.Thumbnail,
+		"id":         blog.ID,
+		"title":      blog.Judul,
+		"content":    blog.Content,
+		"thumbnail":  blog.Thumbnail,
+		"thumbnails": imaging.SrcSet(blog.Thumbnail, ctl.blogs.ThumbnailURL),
 		"author": gin.H{
 			"name":  blog.User.Name,
 			"email": blog.User.Email,
 		},
 		"likes": gin.H{
-			"count":     likesCount,
-			"userLiked": hasLiked,
+			"count":     blog.LikeCount, // The number of likes is stored on the blog
+			"userLiked": detail.Liked,
 		},
-		"comments": comments,
+		"comments": commentResponses(detail.Comments), // Without the commenters' details
 	}
 
 	helpers.SuccessResponse(c, response, "Blog fetched successfully")
+	return nil
 }
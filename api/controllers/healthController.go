@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/health"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/helpers"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// HealthController probes the database and the upload storage.
+type HealthController struct {
+	db    *gorm.DB
+	store storage.Storage
+}
+
+// NewHealthController creates a HealthController.
+func NewHealthController(db *gorm.DB, store storage.Storage) *HealthController {
+	return &HealthController{db: db, store: store}
+}
+
+// Liveness probe
+// @Description Reports that the process is alive; it never checks dependencies
+// @Tags Health
+// @Produce json
+// @Success 200 {object} object{status=string, data=object, message=string}
+// @Router /healthz [get]
+func Healthz(c *gin.Context) {
+	helpers.SuccessResponse(c, gin.H{"status": "alive"}, "Alive")
+}
+
+// Readiness probe
+// @Description Checks the database and the upload storage; 503 when a required dependency is down or the server is shutting down
+// @Tags Health
+// @Produce json
+// @Success 200 {object} object{status=string, data=object, message=string}
+// @Failure 503 {object} object{status=string, data=object, message=string}
+// @Router /readyz [get]
+func (ctl *HealthController) Readyz(c *gin.Context) {
+	checks, ready := health.Run(c.Request.Context(), ctl.readinessChecks())
+
+	switch {
+	case health.Draining():
+		helpers.FailWithData(c, http.StatusServiceUnavailable, helpers.CodeUnavailable, "Server is shutting down", gin.H{"status": "draining", "checks": checks})
+	case !ready:
+		helpers.FailWithData(c, http.StatusServiceUnavailable, helpers.CodeUnavailable, "A required dependency is down", gin.H{"status": "unavailable", "checks": checks})
+	default:
+		helpers.SuccessResponse(c, gin.H{"status": "ready", "checks": checks}, "Ready")
+	}
+}
+
+// readinessChecks lists the dependencies probed by Readyz.
+func (ctl *HealthController) readinessChecks() []health.Check {
+	return []health.Check{
+		{Name: "database", Required: true, Run: ctl.pingDatabase},
+		{Name: "storage", Required: true, Run: ctl.pingStorage},
+	}
+}
+
+// pingDatabase checks that the database answers.
+func (ctl *HealthController) pingDatabase(ctx context.Context) error {
+	if ctl.db == nil {
+		return errors.New("no database configured")
+	}
+	sqlDB, err := ctl.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// pingStorage checks that the storage backend answers and, on the local
+// disk, that uploads can be written.
+func (ctl *HealthController) pingStorage(ctx context.Context) error {
+	if ctl.store == nil {
+		return errors.New("no storage configured")
+	}
+	return ctl.store.Ping(ctx)
+}
+
+// Database health
+// @Description Pings the database and returns the connection pool statistics
+// @Tags Health
+// @Produce json
+// @Success 200 {object} object{status=string, data=object, message=string}
+// @Failure 503 {object} object{status=string, data=object, message=string}
+// @Router /health/db [get]
+func (ctl *HealthController) DatabaseHealth(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), health.Timeout)
+	defer cancel()
+	start := time.Now()
+	err := ctl.pingDatabase(ctx)
+	latency := time.Since(start)
+	var stats initializers.PoolStats
+	if ctl.db != nil {
+		stats, _ = initializers.Stats(ctl.db)
+	}
+	data := gin.H{
+		"latency_ms": latency.Milliseconds(),
+		"pool":       stats,
+	}
+
+	if err != nil {
+		helpers.FailWithData(c, http.StatusServiceUnavailable, helpers.CodeUnavailable, "Database unreachable", data)
+		return
+	}
+
+	helpers.SuccessResponse(c, data, "Database is up")
+}
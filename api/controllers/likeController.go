@@ -2,19 +2,28 @@ package controllers
 
 import (
 	"errors"
-	"net/http"
 	"strconv"
-	"strings"
 
 	"github.com/Tokenzrey/FPPBKKGOLANG/api/middleware"
-	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
+	format_errors "github.com/Tokenzrey/FPPBKKGOLANG/internal/format-errors"
 	"github.com/Tokenzrey/FPPBKKGOLANG/internal/helpers"
-	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/repository"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/service"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/validations"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
-	"gorm.io/gorm"
 )
 
+// LikeController handles liking and unliking blogs.
+type LikeController struct {
+	likes     *service.LikeService
+	validator *validations.Validator
+}
+
+// NewLikeController creates a LikeController.
+func NewLikeController(likes *service.LikeService, validator *validations.Validator) *LikeController {
+	return &LikeController{likes: likes, validator: validator}
+}
+
 // Will generate likes
 // @Description Registers a new row in the "like" table
 // @Tags Like
@@ -23,131 +32,74 @@ import (
 // @Param like body object{ user_id=uint, blog_id=uint }
 // @Success 200 {object} object{status=string, data=models.Like, message=string}
 // @Failure 400 {object} object{status=string, message=string}
-// @Failure 422 {object} object{status=string, message=string}
+// @Failure 422 {object} object{status=string, message=string, code=string, errors=map[string][]string}
 // @Failure 500 {object} object{status=string, message=string}
 // @Router /like/{blog_id}/{user_id} [post]
 
-func GenerateLike(c *gin.Context) {
+func (ctl *LikeController) GenerateLike(c *gin.Context) error {
 	// Extract user ID from the token
-	userID, err := middleware.GetUserIDFromToken(c)
-	if err != nil {
+	userID, ok := middleware.UserID(c)
+	if !ok {
 		// Respond with unauthorized if token is missing, invalid, or expired
-		helpers.ErrorResponse(c, http.StatusUnauthorized, "Token missing, invalid, or expired")
-		return
+		return format_errors.Unauthorized("Token missing, invalid, or expired")
 	}
 
 	// Bind Blog ID from request
 	var blogLiked struct {
-		BlogID uint `json:"blog_id" binding:"required"`
+		BlogID uint `json:"blog_id" validate:"required"`
 	}
 
 	// Bind and validate JSON input
-	if err := c.ShouldBindJSON(&blogLiked); err != nil {
-		if errs, ok := err.(validator.ValidationErrors); ok {
-			// Gabungkan semua pesan error dalam satu string
-			var errorMessage string
-			for _, e := range errs {
-				errorMessage += e.Field() + ": " + e.ActualTag() + "; "
-			}
-			// Trim karakter terakhir
-			errorMessage = strings.TrimSpace(errorMessage)
-
-			helpers.ErrorResponse(c, http.StatusUnprocessableEntity, "Validation failed: "+errorMessage)
-			return
-		}
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Invalid input format")
-		return
-	}
-
-	// Check if blog exists
-	var blog models.Blog
-	if err := initializers.DB.First(&blog, blogLiked.BlogID).Error; err != nil {
-		helpers.ErrorResponse(c, http.StatusNotFound, "Blog not found")
-		return
-	}
-
-	// Check if user already liked blog
-	var already_liked models.Like
-	result := initializers.DB.Where("user_id = ? AND blog_id = ?", userID, blogLiked.BlogID).First(&already_liked)
-
-	if result.Error == nil { // blog is already liked, so we unlike it
-		if err := initializers.DB.Delete(&already_liked).Error; err != nil {
-			helpers.ErrorResponse(c, http.StatusInternalServerError, "Error removing like")
-			return
-		}
-
-		c.JSON(http.StatusOK, gin.H{
-			"message": "Blog unliked successfully",
-			"liked":   false,
-		})
-		return
+	if err := bindJSON(c, ctl.validator, &blogLiked); err != nil {
+		return err
 	}
 
-	// blog is not liked yet
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		newLike := models.Like{
-			UserID: uint(userID),
-			BlogID: blogLiked.BlogID,
-		}
-
-		if err := initializers.DB.Create(&newLike).Error; err != nil {
-			helpers.ErrorResponse(c, http.StatusInternalServerError, "Error liking blog")
-			return
-		}
-
-		c.JSON(http.StatusCreated, gin.H{
-			"message": "Blog liked successfully",
-			"liked":   true,
-		})
-		return
+	// Unlike if the like exists, otherwise like; the blog's counter changes with it
+	liked, err := ctl.likes.Toggle(c.Request.Context(), userID, blogLiked.BlogID)
+
+	switch {
+	case errors.Is(err, service.ErrBlogNotFound):
+		return format_errors.NotFound("Blog not found")
+	case errors.Is(err, repository.ErrConflict):
+		return format_errors.From(err)
+	case err != nil:
+		return format_errors.Internal("Unexpected Error Processing Like", err)
+	case liked:
+		helpers.Created(c, gin.H{"blog_id": blogLiked.BlogID, "liked": true}, "Blog liked successfully")
+	default:
+		helpers.SuccessResponse(c, gin.H{"blog_id": blogLiked.BlogID, "liked": false}, "Blog unliked successfully")
 	}
-
-	helpers.ErrorResponse(c, http.StatusInternalServerError, "Unexpected Error Processing Like")
+	return nil
 }
 
-func ShowLike(c *gin.Context) {
+func (ctl *LikeController) ShowLike(c *gin.Context) error {
 	// Get blog_id from path parameter
 	blogIDStr := c.Param("blog_id")
 	blogID, err := strconv.ParseUint(blogIDStr, 10, 64)
 	if err != nil {
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Invalid blog_id")
-		return
-	}
-
-	// Check if blog exists
-	var blog models.Blog
-	if err := initializers.DB.First(&blog, blogID).Error; err != nil {
-		helpers.ErrorResponse(c, http.StatusNotFound, "Blog not found")
-		return
+		return format_errors.BadRequest("Invalid blog_id")
 	}
 
-	// count the likes for the specified blog
-	var likeCount int64
-	if err := initializers.DB.Model(&models.Like{}).Where("blog_id = ? AND deleted_at IS NULL", blogID).Count(&likeCount).Error; err != nil {
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Error Counting Likes")
-		return
-	}
-
-	// check if user has liked the blog or not
-	var hasLiked bool
-
 	// Extract user ID from the token
-	userID, err := middleware.GetUserIDFromToken(c)
-	if err != nil {
+	userID, ok := middleware.UserID(c)
+	if !ok {
 		// Respond with unauthorized if token is missing, invalid, or expired
-		helpers.ErrorResponse(c, http.StatusUnauthorized, "Token missing, invalid, or expired")
-		return
+		return format_errors.Unauthorized("Token missing, invalid, or expired")
 	}
 
-	var likeExists models.Like
-	// helpers.ErrorResponse(c, http.StatusInternalServerError, "Error Counting Likes 123")
-	result := initializers.DB.Where("user_id = ? AND blog_id = ? AND deleted_at IS NULL", userID, blogID).First(&likeExists)
-	hasLiked = result.Error == nil
+	// Load the blog, whose like count is stored on it, and check if the user has liked it
+	blog, hasLiked, err := ctl.likes.Status(c.Request.Context(), userID, uint(blogID))
+	if err != nil {
+		if errors.Is(err, service.ErrBlogNotFound) {
+			return format_errors.NotFound("Blog not found")
+		}
+		return format_errors.Internal("Error Counting Likes", err)
+	}
 
-	// returns JSON
-	c.JSON(http.StatusOK, gin.H{
+	helpers.SuccessResponse(c, gin.H{
 		"blog_id":       blogID,
-		"likes_count":   likeCount,
+		"likes_count":   blog.LikeCount,
 		"liked_by_user": hasLiked,
-	})
+	}, "Likes retrieved successfully")
+	return nil
 }
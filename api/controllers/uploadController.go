@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	format_errors "github.com/Tokenzrey/FPPBKKGOLANG/internal/format-errors"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// UploadController serves the uploaded files.
+type UploadController struct {
+	store storage.Storage
+}
+
+// NewUploadController creates an UploadController serving the files of store.
+func NewUploadController(store storage.Storage) *UploadController {
+	return &UploadController{store: store}
+}
+
+// ServeUpload streams an uploaded file from the storage backend.
+//
+// @Summary Get uploaded file
+// @Description Returns an uploaded file (e.g. a blog thumbnail) from the configured storage backend.
+// @Tags Upload
+// @Produce octet-stream
+// @Param filepath path string true "File name"
+// @Success 200 {file} binary "File content"
+// @Failure 404 {object} object{status=string,message=string} "File not found"
+// @Router /uploads/{filepath} [get]
+func (ctl *UploadController) ServeUpload(c *gin.Context) error {
+	name := strings.TrimPrefix(c.Param("filepath"), "/")
+	if name == "" {
+		return format_errors.NotFound("File not found")
+	}
+
+	// Open the file from the storage backend
+	file, err := ctl.store.Get(c.Request.Context(), name)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return format_errors.NotFound("File not found")
+		}
+		return format_errors.Internal("Failed to read the file", err)
+	}
+	defer file.Close()
+
+	// Derive the content type from the file extension
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Type", contentType)
+	c.Status(http.StatusOK)
+	io.Copy(c.Writer, file)
+	return nil
+}
@@ -1,23 +1,28 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
-	"os"
-	"strings"
 	"time"
 
 	"github.com/Tokenzrey/FPPBKKGOLANG/api/middleware"
-	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
+	format_errors "github.com/Tokenzrey/FPPBKKGOLANG/internal/format-errors"
 	"github.com/Tokenzrey/FPPBKKGOLANG/internal/helpers"
-	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/service"
 	"github.com/Tokenzrey/FPPBKKGOLANG/internal/validations"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
-	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
 )
 
-var validate = validator.New()
+// UserController handles signup, login and the user's profile.
+type UserController struct {
+	users     *service.UserService
+	validator *validations.Validator
+}
+
+// NewUserController creates a UserController.
+func NewUserController(users *service.UserService, validator *validations.Validator) *UserController {
+	return &UserController{users: users, validator: validator}
+}
 
 // Signup handles user registration
 // @Description Registers a new user with detailed information (name, email, password, tanggal_lahir, biografi).
@@ -27,69 +32,38 @@ var validate = validator.New()
 // @Param user body object{ name=string, email=string, password=string, tanggal_lahir=string, biografi=string } true "User input"
 // @Success 200 {object} object{status=string, data=models.User, message=string}
 // @Failure 400 {object} object{status=string, message=string}
-// @Failure 422 {object} object{status=string, message=string}
+// @Failure 422 {object} object{status=string, message=string, code=string, errors=map[string][]string}
 // @Failure 500 {object} object{status=string, message=string}
 // @Router /signup [post]
-func Signup(c *gin.Context) {
+func (ctl *UserController) Signup(c *gin.Context) error {
 	// Define user input structure
 	var userInput struct {
 		Name         string `json:"name" validate:"required,min=2,max=50"`  // Minimum 2 characters, maximum 50
-		Email        string `json:"email" validate:"required,email"`       // Valid email address required
+		Email        string `json:"email" validate:"required,email,unique_email"` // Valid, unused email address required
 		Password     string `json:"password" validate:"required,min=6"`    // Minimum 6 characters
 		TanggalLahir string `json:"tanggal_lahir" validate:"required,datetime=2006-01-02"` // Date in `YYYY-MM-DD` format
 		Biografi     string `json:"biografi" validate:"required,max=500"`  // Maximum 500 characters
 	}
 
-	// Bind JSON input
-	if err := c.ShouldBindJSON(&userInput); err != nil {
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Invalid input format")
-		return
-	}
-
-	// Validate input fields
-	if err := validate.Struct(userInput); err != nil {
-		if errs, ok := err.(validator.ValidationErrors); ok {
-			// Concatenate all error messages into a single string
-			var errorMessage string
-			for _, e := range errs {
-				errorMessage += e.Field() + ": " + e.ActualTag() + "; "
-			}
-			// Trim the trailing semicolon and space
-			errorMessage = strings.TrimSuffix(errorMessage, "; ")
-
-			helpers.ErrorResponse(c, http.StatusUnprocessableEntity, "Validation failed: "+errorMessage)
-			return
-		}
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Validation error occurred")
-		return
-	}
-
-	// Check if the email already exists in the database
-	if validations.IsUniqueValue("users", "email", userInput.Email) {
-		helpers.ErrorResponse(c, http.StatusUnprocessableEntity, "Email already exists")
-		return
-	}
-
-	// Hash the user's password before saving to the database
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(userInput.Password), bcrypt.DefaultCost)
-	if err != nil {
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Failed to hash password")
-		return
+	// Bind and validate JSON input
+	if err := bindJSON(c, ctl.validator, &userInput); err != nil {
+		return err
 	}
 
-	// Create a new user instance
-	user := models.User{
+	// Hash the password and save the user unless the email is taken
+	user, err := ctl.users.Signup(c.Request.Context(), service.Profile{
 		Name:         userInput.Name,
 		Email:        userInput.Email,
-		Password:     string(hashedPassword),
 		TanggalLahir: userInput.TanggalLahir,
 		Biografi:     userInput.Biografi,
-	}
-
-	// Save the new user to the database
-	if err := initializers.DB.Create(&user).Error; err != nil {
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Failed to create user")
-		return
+	}, userInput.Password)
+	if err != nil {
+		if errors.Is(err, service.ErrEmailTaken) {
+			// Registered by a concurrent signup after the validation
+			lang := c.GetHeader("Accept-Language")
+			return format_errors.Validation(ctl.validator.Message(lang), ctl.validator.Field(lang, "email", "unique_email"))
+		}
+		return format_errors.Internal("Failed to create user", err)
 	}
 
 	// Prepare the response object excluding the password
@@ -111,6 +85,7 @@ func Signup(c *gin.Context) {
 
 	// Respond with the created user details
 	helpers.SuccessResponse(c, userResponse, "User created successfully")
+	return nil
 }
 
 // Login authenticates a user and returns a JWT token
@@ -124,59 +99,25 @@ func Signup(c *gin.Context) {
 // @Failure 401 {object} object{status=string, message=string}
 // @Failure 500 {object} object{status=string, message=string}
 // @Router /login [post]
-func Login(c *gin.Context) {
+func (ctl *UserController) Login(c *gin.Context) error {
 	// Define the structure for user input with validation tags
 	var userInput struct {
 		Email    string `json:"email" validate:"required,email"` // Validate email format
 		Password string `json:"password" validate:"required"`    // Password is required
 	}
 
-	// Bind JSON input
-	if err := c.ShouldBindJSON(&userInput); err != nil {
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Invalid input format")
-		return
-	}
-
-	// Validate input fields
-	if err := validate.Struct(userInput); err != nil {
-		if errs, ok := err.(validator.ValidationErrors); ok {
-			// Concatenate all error messages into a single string
-			var errorMessage string
-			for _, e := range errs {
-				errorMessage += e.Field() + ": " + e.ActualTag() + "; "
-			}
-			// Trim the trailing semicolon and space
-			errorMessage = strings.TrimSuffix(errorMessage, "; ")
-
-			helpers.ErrorResponse(c, http.StatusUnprocessableEntity, "Validation failed: "+errorMessage)
-			return
-		}
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Validation error occurred")
-		return
-	}
-
-	// Find the user in the database using email
-	var user models.User
-	if err := initializers.DB.First(&user, "email = ?", userInput.Email).Error; err != nil || user.ID == 0 {
-		helpers.ErrorResponse(c, http.StatusUnauthorized, "Invalid email or password")
-		return
+	// Bind and validate JSON input
+	if err := bindJSON(c, ctl.validator, &userInput); err != nil {
+		return err
 	}
 
-	// Compare the provided password with the stored hashed password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(userInput.Password)); err != nil {
-		helpers.ErrorResponse(c, http.StatusUnauthorized, "Invalid email or password")
-		return
-	}
-
-	// Generate a JWT token for the authenticated user
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": user.ID,                                    // Subject (user ID)
-		"exp": time.Now().Add(30 * 24 * time.Hour).Unix(), // Expiration (30 days)
-	})
-	tokenString, err := token.SignedString([]byte(os.Getenv("SECRET")))
+	// Check the credentials and generate a JWT token for the authenticated user
+	tokenString, err := ctl.users.Login(c.Request.Context(), userInput.Email, userInput.Password, c.ClientIP())
 	if err != nil {
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Failed to create token")
-		return
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			return format_errors.New(http.StatusUnauthorized, helpers.CodeInvalidCredentials, "Invalid email or password")
+		}
+		return format_errors.Internal("Failed to create token", err)
 	}
 
 	// Return the JWT token in the response
@@ -186,6 +127,7 @@ func Login(c *gin.Context) {
 
 	// Success response with token
 	helpers.SuccessResponse(c, responseData, "Login successful")
+	return nil
 }
 
 // GetUserDetail retrieves user details from the database based on the JWT token.
@@ -196,24 +138,22 @@ func Login(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer token"
-// @Success 200 {object} object{status=string,data=object{id=uint,name=string,email=string,tanggal_lahir=string,biografi=string},message=string} "User retrieved successfully"
+// @Success 200 {object} object{status=string,data=object{id=uint,name=string,email=string,tanggal_lahir=string,biografi=string,last_login_at=string},message=string} "User retrieved successfully"
 // @Failure 401 {object} object{status=string,message=string} "Unauthorized: Token missing, invalid, or expired"
 // @Failure 404 {object} object{status=string,message=string} "User not found"
 // @Router /user/details [get]
-func GetUserDetail(c *gin.Context) {
+func (ctl *UserController) GetUserDetail(c *gin.Context) error {
 	// Extract user ID from the token
-	userID, err := middleware.GetUserIDFromToken(c)
-	if err != nil {
+	userID, ok := middleware.UserID(c)
+	if !ok {
 		// Respond with unauthorized if token is missing, invalid, or expired
-		helpers.ErrorResponse(c, http.StatusUnauthorized, "Token missing, invalid, or expired")
-		return
+		return format_errors.Unauthorized("Token missing, invalid, or expired")
 	}
 
-	// Find the user in the database using the extracted user ID
-	var user models.User
-	if err := initializers.DB.First(&user, userID).Error; err != nil || user.ID == 0 {
-		helpers.ErrorResponse(c, http.StatusNotFound, "User not found")
-		return
+	// Find the user using the extracted user ID
+	user, err := ctl.users.Get(c.Request.Context(), userID)
+	if err != nil {
+		return format_errors.NotFound("User not found")
 	}
 
 	// Prepare response data excluding sensitive fields
@@ -223,10 +163,12 @@ func GetUserDetail(c *gin.Context) {
 		"email":         user.Email,
 		"tanggal_lahir": user.TanggalLahir,
 		"biografi":      user.Biografi,
+		"last_login_at": user.LastLoginAt, // Only shown to the owner
 	}
 
 	// Send successful response
 	helpers.SuccessResponse(c, userResponse, "User retrieved successfully")
+	return nil
 }
 
 // UpdateUser modifies user details in the database.
@@ -241,16 +183,15 @@ func GetUserDetail(c *gin.Context) {
 // @Success 200 {object} object{status=string,data=models.User,message=string} "User updated successfully"
 // @Failure 400 {object} object{status=string,message=string} "Invalid input format"
 // @Failure 404 {object} object{status=string,message=string} "User not found"
-// @Failure 422 {object} object{status=string,message=string} "Validation failed or email already exists"
+// @Failure 422 {object} object{status=string,message=string,code=string,errors=map[string][]string} "Validation failed or email already exists"
 // @Failure 500 {object} object{status=string,message=string} "Internal server error"
 // @Router /users/{id} [put]
-func UpdateUser(c *gin.Context) {
+func (ctl *UserController) UpdateUser(c *gin.Context) error {
 	// Extract user ID from the token
-	id, err := middleware.GetUserIDFromToken(c)
-	if err != nil {
+	id, ok := middleware.UserID(c)
+	if !ok {
 		// Respond with unauthorized if token is missing, invalid, or expired
-		helpers.ErrorResponse(c, http.StatusUnauthorized, "Token missing, invalid, or expired")
-		return
+		return format_errors.Unauthorized("Token missing, invalid, or expired")
 	}
 
 	// Define the structure for input validation
@@ -261,57 +202,30 @@ func UpdateUser(c *gin.Context) {
 		Biografi     string `json:"biografi" validate:"omitempty,max=500"`     // Biografi: Optional, Max 500 characters
 	}
 
-	// Bind JSON input
-	if err := c.ShouldBindJSON(&userInput); err != nil {
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Invalid input format")
-		return
-	}
-
-	// Validate input fields
-	if err := validate.Struct(userInput); err != nil {
-		if errs, ok := err.(validator.ValidationErrors); ok {
-			// Concatenate all error messages into a single string
-			var errorMessage string
-			for _, e := range errs {
-				errorMessage += e.Field() + ": " + e.ActualTag() + "; "
-			}
-			// Trim the trailing semicolon and space
-			errorMessage = strings.TrimSuffix(errorMessage, "; ")
-
-			helpers.ErrorResponse(c, http.StatusUnprocessableEntity, "Validation failed: "+errorMessage)
-			return
-		}
-		helpers.ErrorResponse(c, http.StatusBadRequest, "Validation error occurred")
-		return
-	}
-
-	// Find the user in the database
-	var user models.User
-	if err := initializers.DB.First(&user, id).Error; err != nil {
-		helpers.ErrorResponse(c, http.StatusNotFound, "User not found")
-		return
+	// Bind and validate JSON input
+	if err := bindJSON(c, ctl.validator, &userInput); err != nil {
+		return err
 	}
 
-	// If the new email is different, check if it's unique
-	if user.Email != userInput.Email {
-		if validations.IsUniqueValue("users", "email", userInput.Email) {
-			helpers.ErrorResponse(c, http.StatusUnprocessableEntity, "Email already exists")
-			return
+	// Save the new profile unless the new email belongs to someone else
+	user, err := ctl.users.Update(c.Request.Context(), id, service.Profile{
+		Name:         userInput.Name,
+		Email:        userInput.Email,
+		TanggalLahir: userInput.TanggalLahir,
+		Biografi:     userInput.Biografi,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			return format_errors.NotFound("User not found")
+		case errors.Is(err, service.ErrEmailTaken):
+			lang := c.GetHeader("Accept-Language")
+			return format_errors.Validation(ctl.validator.Message(lang), ctl.validator.Field(lang, "email", "unique_email"))
+		default:
+			return format_errors.Internal("Failed to update user", err)
 		}
 	}
 
-	// Update user fields
-	user.Name = userInput.Name
-	user.Email = userInput.Email
-	user.TanggalLahir = userInput.TanggalLahir
-	user.Biografi = userInput.Biografi
-
-	// Save changes to the database
-	if err := initializers.DB.Save(&user).Error; err != nil {
-		helpers.ErrorResponse(c, http.StatusInternalServerError, "Failed to update user")
-		return
-	}
-
 	// Respond with the updated user data
 	userResponse := gin.H{
 		"id":            user.ID,
@@ -321,4 +235,5 @@ func UpdateUser(c *gin.Context) {
 		"biografi":      user.Biografi,
 	}
 	helpers.SuccessResponse(c, userResponse, "User updated successfully")
+	return nil
 }
@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	format_errors "github.com/Tokenzrey/FPPBKKGOLANG/internal/format-errors"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/validations"
+	"github.com/gin-gonic/gin"
+)
+
+// bindJSON decodes the request body into input and validates it. Malformed
+// JSON is a bad request, invalid fields a validation error.
+func bindJSON(c *gin.Context, v *validations.Validator, input any) error {
+	if err := c.ShouldBindJSON(input); err != nil {
+		return format_errors.BadRequest("Invalid input format")
+	}
+
+	lang := c.GetHeader("Accept-Language")
+	if errs := v.Struct(c.Request.Context(), lang, input); errs != nil {
+		return format_errors.Validation(v.Message(lang), errs)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	format_errors "github.com/Tokenzrey/FPPBKKGOLANG/internal/format-errors"
+	"github.com/gin-gonic/gin"
+)
+
+// Errors renders the error a handler or middleware added to the context,
+// as problem+json or in the API envelope depending on the Accept header.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		format_errors.Render(c, c.Errors.Last().Err)
+	}
+}
+
+// Handle adapts a handler that returns its error, leaving the response to
+// Errors.
+func Handle(h func(c *gin.Context) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := h(c); err != nil {
+			_ = c.Error(err)
+			c.Abort()
+		}
+	}
+}
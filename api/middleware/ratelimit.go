@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	format_errors "github.com/Tokenzrey/FPPBKKGOLANG/internal/format-errors"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/logging"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/metrics"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc names the client whose bucket a request takes from.
+type KeyFunc func(c *gin.Context) string
+
+// ByIP limits each client IP.
+func ByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUser limits each authenticated user, and clients without a user by IP.
+// It runs after RequireAuth.
+func ByUser(c *gin.Context) string {
+	if id, ok := c.Get(UserIDKey); ok {
+		return fmt.Sprintf("user:%d", id)
+	}
+	return ByIP(c)
+}
+
+// RateLimit refuses the requests of a client whose bucket in limiter is
+// empty with 429 and Retry-After. Every response reports the limit in the
+// RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy
+// headers. When the store fails, requests are let through so an outage of
+// Redis does not take the API down. A nil limiter does not limit.
+func RateLimit(limiter *ratelimit.Limiter, key KeyFunc) gin.HandlerFunc {
+	if limiter == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	policy := fmt.Sprintf("%d;w=%d", limiter.Limit.Requests, int(limiter.Limit.Period.Seconds()))
+	return func(c *gin.Context) {
+		res, err := limiter.Take(c.Request.Context(), key(c))
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Warn("Rate limit store failed", "group", limiter.Name, "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		c.Header("RateLimit-Policy", policy)
+		if !res.Allowed {
+			metrics.RateLimited.WithLabelValues(limiter.Name).Inc()
+			_ = c.Error(format_errors.RateLimited("Too many requests, try again later", res.RetryAfter))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ceilSeconds rounds d up to whole seconds.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
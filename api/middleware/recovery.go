@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"runtime/debug"
+
+	format_errors "github.com/Tokenzrey/FPPBKKGOLANG/internal/format-errors"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery turns a panicking handler into a 500 response rendered like any
+// other error, and logs the panic with its stack trace.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		logging.FromContext(c.Request.Context()).Error("panic recovered", "panic", recovered, "stack", string(debug.Stack()))
+		err := format_errors.Internal("Internal server error", fmt.Errorf("panic: %v", recovered))
+		_ = c.Error(err) // For the request log
+		format_errors.Render(c, err)
+	})
+}
@@ -2,71 +2,78 @@ package middleware
 
 import (
 	"errors"
-	"net/http"
-	"os"
 	"strings"
 
-	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
-	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	format_errors "github.com/Tokenzrey/FPPBKKGOLANG/internal/format-errors"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/logging"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/service"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
+// UserIDKey holds the ID of the authenticated user in the gin context.
+const UserIDKey = "userID"
+
 type AuthUser struct {
 	ID    uint   `json:"ID"`
 	Name  string `json:"Name"`
 	Email string `json:"Email"`
 }
 
-// GetUserIDFromToken extracts the user ID (sub) from a JWT token.
-func GetUserIDFromToken(c *gin.Context) (float64, error) {
+// bearerToken returns the token of the Authorization header.
+func bearerToken(c *gin.Context) (string, error) {
 	// Extract the Authorization header
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
-		return 0, errors.New("missing Authorization header")
+		return "", errors.New("missing Authorization header")
 	}
 
 	// Ensure the token uses "Bearer" format
 	if !strings.HasPrefix(authHeader, "Bearer ") {
-		return 0, errors.New("invalid Authorization header format")
-	}
-	tokenStr := authHeader[len("Bearer "):]
-
-	// Parse the JWT token and validate its signature
-	claims := jwt.MapClaims{}
-	_, err := jwt.ParseWithClaims(tokenStr, &claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("SECRET")), nil
-	})
-	if err != nil {
-		return 0, errors.New("invalid or expired token")
+		return "", errors.New("invalid Authorization header format")
 	}
+	return authHeader[len("Bearer "):], nil
+}
 
-	// Extract the user ID ("sub") from the claims
-	userID, ok := claims["sub"].(float64)
+// UserID returns the ID of the user authenticated by RequireAuth.
+func UserID(c *gin.Context) (uint, bool) {
+	id, ok := c.Get(UserIDKey)
 	if !ok {
-		return 0, errors.New("user ID not found in token payload")
+		return 0, false
 	}
-
-	return userID, nil
+	userID, ok := id.(uint)
+	return userID, ok
 }
 
-// RequireAuth is a middleware to check for user authentication and attach user info to context.
-func RequireAuth(c *gin.Context) {
-	// Extract user ID from the token
-	userID, err := GetUserIDFromToken(c)
-	if err != nil {
-		// Respond with unauthorized if token is missing, invalid, or expired
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
+// RequireAuth returns a middleware that rejects requests without a valid
+// token of an existing user; the error is rendered by Errors. The token is
+// verified by users, which signed it.
+func RequireAuth(users *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Extract the user ID from the token
+		var userID uint
+		token, err := bearerToken(c)
+		if err == nil {
+			userID, err = users.VerifyToken(token)
+		}
+		if err != nil {
+			// Respond with unauthorized if token is missing, invalid, or expired
+			_ = c.Error(format_errors.Unauthorized(err.Error()))
+			c.Abort()
+			return
+		}
 
-	// Find the user in the database using the extracted user ID
-	var user models.User
-	if err := initializers.DB.First(&user, userID).Error; err != nil || user.ID == 0 {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
+		// Make sure the user still exists
+		if _, err := users.Get(c.Request.Context(), userID); err != nil {
+			_ = c.Error(format_errors.Unauthorized("Unauthorized"))
+			c.Abort()
+			return
+		}
 
-	// Continue to the next middleware or handler
-	c.Next()
+		// Log the user with every line of the request and limit their rate
+		c.Set(UserIDKey, userID)
+		c.Request = c.Request.WithContext(logging.With(c.Request.Context(), "user_id", userID))
+
+		// Continue to the next middleware or handler
+		c.Next()
+	}
 }
@@ -5,41 +5,114 @@ import (
 
 	"github.com/Tokenzrey/FPPBKKGOLANG/api/controllers"
 	"github.com/Tokenzrey/FPPBKKGOLANG/api/middleware"
+	"github.com/Tokenzrey/FPPBKKGOLANG/config"
+	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
+	format_errors "github.com/Tokenzrey/FPPBKKGOLANG/internal/format-errors"
 	"github.com/Tokenzrey/FPPBKKGOLANG/internal/helpers"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/mail"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/metrics"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/ratelimit"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/repository"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/search"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/service"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/storage"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/suggest"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/validations"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// Dependencies are what the handlers use besides the services.
+type Dependencies struct {
+	DB         *gorm.DB        // Probed by the health checks
+	Storage    storage.Storage // Serves the uploads and is probed by /readyz
+	RateLimits ratelimit.Groups
+}
+
+// GetRoute registers the routes with services built on the application's
+// database, storage, search and suggestion backends and mail sender, and
+// trusts the X-Forwarded-For of the configured proxies only.
 func GetRoute(r *gin.Engine) {
-	// Middleware untuk menangani rute yang tidak ditemukan
-	r.NoRoute(func(c *gin.Context) {
-		helpers.ErrorResponse(c, http.StatusNotFound, "Route not found")
+	if err := r.SetTrustedProxies(config.App.Server.Proxies()); err != nil {
+		panic("Trusted proxies: " + err.Error())
+	}
+
+	services := service.New(repository.NewGORM(initializers.DB), service.Options{
+		Storage:     storage.Default,
+		Searcher:    search.Default,
+		Suggestions: suggest.Default,
+		Secret:      config.App.Secret,
+		Lockout: service.Lockout{
+			Threshold:   config.App.Lockout.Threshold,
+			Duration:    config.App.Lockout.Duration,
+			MaxDuration: config.App.Lockout.MaxDuration,
+		},
+		Mailer: mail.Default,
 	})
+	Register(r, services, Dependencies{DB: initializers.DB, Storage: storage.Default, RateLimits: ratelimit.Default})
+}
+
+// Register registers the routes with handlers using services and deps. The
+// tokens are verified by services.Users, which signs them.
+func Register(r *gin.Engine, services service.Services, deps Dependencies) {
+	validator := validations.New()
+	if err := validator.RegisterUniqueEmail(services.Users.EmailTaken); err != nil {
+		panic(err)
+	}
+
+	users := controllers.NewUserController(services.Users, validator)
+	blogs := controllers.NewBlogController(services.Blogs)
+	likes := controllers.NewLikeController(services.Likes, validator)
+	comments := controllers.NewCommentController(services.Comments, validator)
+	uploads := controllers.NewUploadController(deps.Storage)
+	health := controllers.NewHealthController(deps.DB, deps.Storage)
+
+	// Every request is logged, traced and measured; panics and the errors of
+	// handlers are answered like every other error
+	r.Use(middleware.RequestLogger(), middleware.Tracing(), middleware.Metrics(), middleware.Recovery(), middleware.Errors())
+	h := middleware.Handle
+
+	// Guessing passwords and spamming are limited per client IP and per user
+	limitAuth := middleware.RateLimit(deps.RateLimits.Auth, middleware.ByIP)
+	limitWrite := middleware.RateLimit(deps.RateLimits.Write, middleware.ByUser)
+
+	// Middleware untuk menangani rute yang tidak ditemukan
+	r.NoRoute(h(func(c *gin.Context) error {
+		return format_errors.New(http.StatusNotFound, helpers.CodeRouteNotFound, "Route not found")
+	}))
 
 	// Public routes (no authentication required)
-	r.POST("/api/signup", controllers.Signup)           // User signup
-	r.POST("/api/login", controllers.Login)             // User login
-	r.GET("/api/blogs", controllers.GetBlogs)           // Get paginated blogs
-	r.GET("/api/blogs/search", controllers.SearchBlogs) // Search blogs by query
-	r.GET("/api/blog/:id", controllers.GetBlog)
+	r.POST("/api/signup", limitAuth, h(users.Signup))   // User signup
+	r.POST("/api/login", limitAuth, h(users.Login))     // User login
+	r.GET("/api/blogs", h(blogs.GetBlogs))              // Get paginated blogs
+	r.GET("/api/blogs/search", h(blogs.SearchBlogs))    // Search blogs by query
+	r.GET("/api/blogs/suggest", h(blogs.SuggestBlogs))  // Search-box completions
+	r.GET("/uploads/*filepath", h(uploads.ServeUpload)) // Uploaded thumbnails
+	r.GET("/healthz", controllers.Healthz)              // Liveness probe
+	r.GET("/readyz", health.Readyz)                     // Readiness probe
+	r.GET("/health/db", health.DatabaseHealth)          // Database ping and pool statistics
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))     // Prometheus metrics
 	// Routes requiring authentication
 	authRouter := r.Group("/")
-	authRouter.Use(middleware.RequireAuth)
+	authRouter.Use(middleware.RequireAuth(services.Users))
 	{
 		// User-related routes
 		userRouter := authRouter.Group("/api/users")
 		{
-			userRouter.GET("/", controllers.GetUserDetail)    // Get user details
-			userRouter.PUT("/update", controllers.UpdateUser) // Update user details
+			userRouter.GET("/", h(users.GetUserDetail))                // Get user details
+			userRouter.PUT("/update", limitWrite, h(users.UpdateUser)) // Update user details
 		}
 
 		blogsRouter := authRouter.Group("/api/blogs")
 		{
-			blogsRouter.POST("/", controllers.PostBlog)
-			blogsRouter.DELETE("/:id", controllers.DeleteBlog)
+			blogsRouter.POST("/", limitWrite, h(blogs.PostBlog))
+			blogsRouter.DELETE("/:id", limitWrite, h(blogs.DeleteBlog))
 		}
-		authRouter.POST("/like", controllers.GenerateLike)
-		authRouter.GET("/api/blogs/like/:blog_id", controllers.ShowLike)
-		authRouter.POST("/comment", controllers.PostComment)
-		authRouter.GET("/api/blogs/comment/:blog_id", controllers.ShowComments)
+		authRouter.GET("/api/blog/:id", h(blogs.GetBlog)) // Tells whether the user likes it
+		authRouter.POST("/like", limitWrite, h(likes.GenerateLike))
+		authRouter.GET("/api/blogs/like/:blog_id", h(likes.ShowLike))
+		authRouter.POST("/comment", limitWrite, h(comments.PostComment))
+		authRouter.DELETE("/comment/:id", limitWrite, h(comments.DeleteComment))
+		authRouter.GET("/api/blogs/comment/:blog_id", h(comments.ShowComments))
 	}
 }
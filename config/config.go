@@ -0,0 +1,351 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MinSecretLength is the minimum length of the JWT signing secret.
+const MinSecretLength = 32
+
+// Config is the typed application configuration. Every field can be set in
+// the optional config file (YAML or TOML, keys as in the yaml/toml tags), in
+// a .env file or in the environment variable named by its env tag; the
+// environment wins, then .env, then the file, then the default tag.
+// Fields tagged secret are hidden by Redacted.
+type Config struct {
+	Env    string `yaml:"env" toml:"env" env:"APP_ENV" default:"development"`
+	Port   string `yaml:"port" toml:"port" env:"PORT" default:"3000"`
+	Secret string `yaml:"secret" toml:"secret" env:"SECRET" secret:"true"`
+
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	Database  DatabaseConfig  `yaml:"database" toml:"database"`
+	Storage   StorageConfig   `yaml:"storage" toml:"storage"`
+	Search    SearchConfig    `yaml:"search" toml:"search"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+	Tracing   TracingConfig   `yaml:"tracing" toml:"tracing"`
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	Lockout   LockoutConfig   `yaml:"lockout" toml:"lockout"`
+	Mail      MailConfig      `yaml:"mail" toml:"mail"`
+}
+
+// ServerConfig configures the HTTP server and its shutdown.
+type ServerConfig struct {
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout" env:"SERVER_READ_TIMEOUT" default:"30s"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" default:"10s"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout" env:"SERVER_WRITE_TIMEOUT" default:"30s"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" default:"120s"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" toml:"max_header_bytes" env:"SERVER_MAX_HEADER_BYTES" default:"1048576"`
+
+	// DrainDelay is how long /readyz fails before the server stops accepting
+	// connections, so load balancers stop routing to it first
+	DrainDelay      time.Duration `yaml:"drain_delay" toml:"drain_delay" env:"SHUTDOWN_DRAIN_DELAY" default:"5s"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"30s"`
+
+	// TrustedProxies is a comma-separated list of the IPs and CIDRs of the
+	// proxies whose X-Forwarded-For is believed. None by default: the
+	// client IP is the address of the connection, which clients cannot forge
+	TrustedProxies string `yaml:"trusted_proxies" toml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+}
+
+// Proxies returns the entries of TrustedProxies, nil when there are none.
+func (c ServerConfig) Proxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(c.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// DatabaseConfig configures the database connection and its pool.
+// Durations are written like "30s" or "5m".
+type DatabaseConfig struct {
+	Driver string `yaml:"driver" toml:"driver" env:"DB_DRIVER" default:"mysql"`
+	DSN    string `yaml:"dsn" toml:"dsn" env:"DNS" secret:"dsn"`
+
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns" env:"DB_MAX_OPEN_CONNS" default:"25"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" default:"10"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" default:"30m"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" default:"5m"`
+
+	ConnectRetries     int           `yaml:"connect_retries" toml:"connect_retries" env:"DB_CONNECT_RETRIES" default:"5"`
+	RetryBackoff       time.Duration `yaml:"retry_backoff" toml:"retry_backoff" env:"DB_RETRY_BACKOFF" default:"1s"`
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold" toml:"slow_query_threshold" env:"DB_SLOW_QUERY_THRESHOLD" default:"200ms"`
+}
+
+// StorageConfig configures where uploaded files are stored.
+type StorageConfig struct {
+	Driver    string `yaml:"driver" toml:"driver" env:"STORAGE_DRIVER" default:"local"`
+	Dir       string `yaml:"dir" toml:"dir" env:"UPLOAD_DIR" default:"./uploads"`
+	URLPrefix string `yaml:"url_prefix" toml:"url_prefix" env:"UPLOAD_URL_PREFIX" default:"/uploads"`
+
+	S3Endpoint  string `yaml:"s3_endpoint" toml:"s3_endpoint" env:"S3_ENDPOINT"`
+	S3Region    string `yaml:"s3_region" toml:"s3_region" env:"S3_REGION" default:"us-east-1"`
+	S3Bucket    string `yaml:"s3_bucket" toml:"s3_bucket" env:"S3_BUCKET"`
+	S3AccessKey string `yaml:"s3_access_key" toml:"s3_access_key" env:"S3_ACCESS_KEY"`
+	S3SecretKey string `yaml:"s3_secret_key" toml:"s3_secret_key" env:"S3_SECRET_KEY" secret:"true"`
+	S3PublicURL string `yaml:"s3_public_url" toml:"s3_public_url" env:"S3_PUBLIC_URL"`
+}
+
+// SearchConfig configures the blog search backend.
+type SearchConfig struct {
+	Backend string `yaml:"backend" toml:"backend" env:"SEARCH_BACKEND" default:"sql"`
+}
+
+// LogConfig configures the application log.
+type LogConfig struct {
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT" default:"text"`
+	Level  string `yaml:"level" toml:"level" env:"LOG_LEVEL" default:"info"`
+}
+
+// TracingConfig configures where the OpenTelemetry traces are exported.
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER" default:"none"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint" toml:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT"`
+	ServiceName  string  `yaml:"service_name" toml:"service_name" env:"TRACING_SERVICE_NAME" default:"blog-api"`
+	SampleRatio  float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" default:"1"`
+}
+
+// RateLimitConfig configures the request rate limits of the route groups.
+// A limit is written like "10/1m", 10 requests a minute in bursts of up to
+// 10; "0" disables it. See ParseRate.
+type RateLimitConfig struct {
+	Store    string `yaml:"store" toml:"store" env:"RATE_LIMIT_STORE" default:"memory"`
+	RedisURL string `yaml:"redis_url" toml:"redis_url" env:"RATE_LIMIT_REDIS_URL" secret:"dsn"`
+
+	Auth  string `yaml:"auth" toml:"auth" env:"RATE_LIMIT_AUTH" default:"10/1m"`    // Signup and login, by client IP
+	Write string `yaml:"write" toml:"write" env:"RATE_LIMIT_WRITE" default:"30/1m"` // Posts, likes and comments, by user
+}
+
+// LockoutConfig configures how accounts are locked after failed logins.
+// Each lockout in a row lasts twice as long as the previous one, up to
+// MaxDuration; a threshold of 0 disables locking.
+type LockoutConfig struct {
+	Threshold   int           `yaml:"threshold" toml:"threshold" env:"LOCKOUT_THRESHOLD" default:"5"`
+	Duration    time.Duration `yaml:"duration" toml:"duration" env:"LOCKOUT_DURATION" default:"1m"`
+	MaxDuration time.Duration `yaml:"max_duration" toml:"max_duration" env:"LOCKOUT_MAX_DURATION" default:"1h"`
+}
+
+// MailConfig configures how emails are sent.
+type MailConfig struct {
+	Driver string `yaml:"driver" toml:"driver" env:"MAIL_DRIVER" default:"log"`
+	From   string `yaml:"from" toml:"from" env:"MAIL_FROM" default:"no-reply@localhost"`
+
+	SMTPHost     string `yaml:"smtp_host" toml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     string `yaml:"smtp_port" toml:"smtp_port" env:"SMTP_PORT" default:"587"`
+	SMTPUsername string `yaml:"smtp_username" toml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" toml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+}
+
+// ParseRate parses a rate limit such as "10/1m" into its number of requests
+// and period. "0" parses as no requests, which means no limit.
+func ParseRate(rate string) (requests int, period time.Duration, err error) {
+	if rate == "0" {
+		return 0, 0, nil
+	}
+	count, per, found := strings.Cut(rate, "/")
+	if !found {
+		return 0, 0, fmt.Errorf("rate %q is not written like 10/1m", rate)
+	}
+	requests, err = strconv.Atoi(count)
+	if err != nil || requests <= 0 {
+		return 0, 0, fmt.Errorf("rate %q must allow a positive number of requests", rate)
+	}
+	period, err = time.ParseDuration(per)
+	if err != nil || period <= 0 {
+		return 0, 0, fmt.Errorf("rate %q must have a positive period such as 1m", rate)
+	}
+	return requests, period, nil
+}
+
+// Validate checks required fields and allowed values, reporting every problem at once.
+func (c *Config) Validate() error {
+	var errs []error
+
+	switch c.Env {
+	case "development", "test", "production":
+	default:
+		errs = append(errs, fmt.Errorf("APP_ENV must be 'development', 'test' or 'production', got %q", c.Env))
+	}
+
+	if c.Secret == "" {
+		errs = append(errs, errors.New("SECRET is required"))
+	} else if len(c.Secret) < MinSecretLength {
+		errs = append(errs, fmt.Errorf("SECRET must be at least %d characters long", MinSecretLength))
+	}
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port <= 0 || port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT must be a number between 1 and 65535, got %q", c.Port))
+	}
+
+	for name, value := range map[string]int64{
+		"SERVER_READ_TIMEOUT":        int64(c.Server.ReadTimeout),
+		"SERVER_READ_HEADER_TIMEOUT": int64(c.Server.ReadHeaderTimeout),
+		"SERVER_WRITE_TIMEOUT":       int64(c.Server.WriteTimeout),
+		"SERVER_IDLE_TIMEOUT":        int64(c.Server.IdleTimeout),
+		"SERVER_MAX_HEADER_BYTES":    int64(c.Server.MaxHeaderBytes),
+		"SHUTDOWN_DRAIN_DELAY":       int64(c.Server.DrainDelay),
+		"SHUTDOWN_TIMEOUT":           int64(c.Server.ShutdownTimeout),
+	} {
+		if value < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", name))
+		}
+	}
+
+	for _, proxy := range c.Server.Proxies() {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			errs = append(errs, fmt.Errorf("TRUSTED_PROXIES must list IPs or CIDRs, got %q", proxy))
+		}
+	}
+
+	switch c.Database.Driver {
+	case "mysql", "postgres", "sqlite":
+	default:
+		errs = append(errs, fmt.Errorf("DB_DRIVER must be 'mysql', 'postgres' or 'sqlite', got %q", c.Database.Driver))
+	}
+	if c.Database.DSN == "" {
+		errs = append(errs, errors.New("DNS (database connection string) is required"))
+	}
+	for name, value := range map[string]int64{
+		"DB_MAX_OPEN_CONNS":       int64(c.Database.MaxOpenConns),
+		"DB_MAX_IDLE_CONNS":       int64(c.Database.MaxIdleConns),
+		"DB_CONN_MAX_LIFETIME":    int64(c.Database.ConnMaxLifetime),
+		"DB_CONN_MAX_IDLE_TIME":   int64(c.Database.ConnMaxIdleTime),
+		"DB_CONNECT_RETRIES":      int64(c.Database.ConnectRetries),
+		"DB_RETRY_BACKOFF":        int64(c.Database.RetryBackoff),
+		"DB_SLOW_QUERY_THRESHOLD": int64(c.Database.SlowQueryThreshold),
+	} {
+		if value < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", name))
+		}
+	}
+
+	switch c.Storage.Driver {
+	case "local":
+		if c.Storage.Dir == "" {
+			errs = append(errs, errors.New("UPLOAD_DIR is required for the local storage driver"))
+		}
+	case "s3":
+		for name, value := range map[string]string{
+			"S3_ENDPOINT":   c.Storage.S3Endpoint,
+			"S3_BUCKET":     c.Storage.S3Bucket,
+			"S3_ACCESS_KEY": c.Storage.S3AccessKey,
+			"S3_SECRET_KEY": c.Storage.S3SecretKey,
+		} {
+			if value == "" {
+				errs = append(errs, fmt.Errorf("%s is required for the s3 storage driver", name))
+			}
+		}
+	default:
+		errs = append(errs, fmt.Errorf("STORAGE_DRIVER must be 'local' or 's3', got %q", c.Storage.Driver))
+	}
+
+	if c.Search.Backend != "sql" && c.Search.Backend != "memory" {
+		errs = append(errs, fmt.Errorf("SEARCH_BACKEND must be 'sql' or 'memory', got %q", c.Search.Backend))
+	}
+
+	if c.Log.Format != "text" && c.Log.Format != "json" {
+		errs = append(errs, fmt.Errorf("LOG_FORMAT must be 'text' or 'json', got %q", c.Log.Format))
+	}
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("LOG_LEVEL must be 'debug', 'info', 'warn' or 'error', got %q", c.Log.Level))
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		if c.Tracing.OTLPEndpoint == "" {
+			errs = append(errs, errors.New("TRACING_OTLP_ENDPOINT is required for the otlp exporter"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("TRACING_EXPORTER must be 'none', 'stdout' or 'otlp', got %q", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("TRACING_SAMPLE_RATIO must be between 0 and 1, got %v", c.Tracing.SampleRatio))
+	}
+
+	switch c.RateLimit.Store {
+	case "memory":
+	case "redis":
+		if c.RateLimit.RedisURL == "" {
+			errs = append(errs, errors.New("RATE_LIMIT_REDIS_URL is required for the redis rate limit store"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_STORE must be 'memory' or 'redis', got %q", c.RateLimit.Store))
+	}
+	for name, value := range map[string]string{
+		"RATE_LIMIT_AUTH":  c.RateLimit.Auth,
+		"RATE_LIMIT_WRITE": c.RateLimit.Write,
+	} {
+		if _, _, err := ParseRate(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	if c.Lockout.Threshold < 0 {
+		errs = append(errs, errors.New("LOCKOUT_THRESHOLD must not be negative"))
+	}
+	if c.Lockout.Threshold > 0 && (c.Lockout.Duration <= 0 || c.Lockout.MaxDuration < c.Lockout.Duration) {
+		errs = append(errs, errors.New("LOCKOUT_DURATION must be positive and at most LOCKOUT_MAX_DURATION"))
+	}
+
+	switch c.Mail.Driver {
+	case "log":
+	case "smtp":
+		if c.Mail.SMTPHost == "" {
+			errs = append(errs, errors.New("SMTP_HOST is required for the smtp mail driver"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("MAIL_DRIVER must be 'log' or 'smtp', got %q", c.Mail.Driver))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Addr returns the listen address for the HTTP server.
+func (c *Config) Addr() string {
+	return ":" + c.Port
+}
+
+// Redacted returns one "ENV_NAME=value" line per setting, sorted as declared,
+// with secrets masked so the result can be written to startup logs.
+func (c *Config) Redacted() string {
+	var lines []string
+	walk(c, func(f field) {
+		value := fmt.Sprint(f.value.Interface())
+		switch {
+		case value == "":
+		case f.secret == "dsn":
+			value = redactDSN(value)
+		case f.secret != "":
+			value = "********"
+		}
+		lines = append(lines, f.env+"="+value)
+	})
+	return strings.Join(lines, "\n")
+}
+
+// redactDSN hides the password of a "user:password@..." or URL-style connection string.
+func redactDSN(dsn string) string {
+	at := strings.LastIndex(dsn, "@")
+	if at < 0 {
+		return "********"
+	}
+	credentials := dsn[:at]
+	start := 0
+	if scheme := strings.Index(credentials, "//"); scheme >= 0 {
+		start = scheme + 2 // Skip "scheme://"
+	}
+	if colon := strings.Index(credentials[start:], ":"); colon >= 0 {
+		return credentials[:start+colon+1] + "********" + dsn[at:]
+	}
+	return dsn
+}
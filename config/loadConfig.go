@@ -0,0 +1,197 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// App is the configuration loaded by Init.
+var App *Config
+
+// Init loads the configuration into App and stops the process when it is invalid.
+func Init() {
+	cfg, err := Load()
+	if err != nil {
+		log.Fatal("Invalid configuration:\n", err)
+	}
+	App = cfg
+}
+
+// Load builds the configuration from, in increasing priority: the default
+// tags, the YAML or TOML file named by CONFIG_FILE, the given .env files
+// (".env" when none are given) and the process environment. Missing .env
+// files are ignored so containers can rely on real environment variables.
+func Load(envFiles ...string) (*Config, error) {
+	if len(envFiles) == 0 {
+		envFiles = []string{".env"}
+	}
+	for _, file := range envFiles {
+		// godotenv never overrides variables that are already set
+		if err := godotenv.Load(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("load %s: %w", file, err)
+		}
+	}
+
+	cfg := &Config{}
+	var errs []error
+	walk(cfg, func(f field) {
+		if f.def != "" {
+			if err := setValue(f.value, f.def); err != nil {
+				errs = append(errs, fmt.Errorf("default of %s: %w", f.env, err))
+			}
+		}
+	})
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := loadFile(path, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	walk(cfg, func(f field) {
+		if value, ok := os.LookupEnv(f.env); ok && value != "" {
+			if err := setValue(f.value, value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", f.env, err))
+			}
+		}
+	})
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadFile reads a YAML (.yaml, .yml) or TOML (.toml) config file into cfg.
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".toml":
+		// go-toml cannot decode "30s" into a time.Duration, so the values
+		// are applied through setValue like environment variables
+		var values map[string]any
+		if err = toml.Unmarshal(data, &values); err == nil {
+			err = applyValues(reflect.ValueOf(cfg).Elem(), values)
+		}
+	default:
+		return fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// applyValues sets the fields of v from a decoded TOML table, keyed by toml tag.
+func applyValues(v reflect.Value, values map[string]any) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		raw, ok := values[sf.Tag.Get("toml")]
+		if !ok {
+			continue
+		}
+		if sf.Type.Kind() == reflect.Struct && sf.Type != reflect.TypeOf(time.Duration(0)) {
+			table, ok := raw.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s must be a table", sf.Tag.Get("toml"))
+			}
+			if err := applyValues(v.Field(i), table); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := setValue(v.Field(i), fmt.Sprint(raw)); err != nil {
+			return fmt.Errorf("%s: %w", sf.Tag.Get("toml"), err)
+		}
+	}
+	return nil
+}
+
+// field is a configuration setting found by walk.
+type field struct {
+	env    string        // Environment variable name
+	def    string        // Default value
+	secret string        // "true" to mask, "dsn" to mask the password only
+	value  reflect.Value // Settable value inside the Config
+}
+
+// walk calls fn for every field with an env tag, descending into nested structs.
+func walk(cfg *Config, fn func(field)) {
+	walkValue(reflect.ValueOf(cfg).Elem(), fn)
+}
+
+func walkValue(v reflect.Value, fn func(field)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.Type.Kind() == reflect.Struct && sf.Type != reflect.TypeOf(time.Duration(0)) {
+			walkValue(v.Field(i), fn)
+			continue
+		}
+		if env := sf.Tag.Get("env"); env != "" {
+			fn(field{env: env, def: sf.Tag.Get("default"), secret: sf.Tag.Get("secret"), value: v.Field(i)})
+		}
+	}
+}
+
+// setValue parses raw into v according to its type.
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported config type %s", v.Type())
+	}
+	return nil
+}
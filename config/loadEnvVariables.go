@@ -1,14 +0,0 @@
-package config
-
-import (
-	"github.com/joho/godotenv"
-	"log"
-)
-
-func LoadEnvVariables() {
-	err := godotenv.Load()
-
-	if err != nil {
-		log.Fatal("Error loading .env file")
-	}
-}
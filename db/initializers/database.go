@@ -1,20 +1,156 @@
 package initializers
 
 import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/config"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/logging"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/metrics"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/tracing"
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"os"
 )
 
+// maxRetryBackoff caps the wait between two connection attempts.
+const maxRetryBackoff = 30 * time.Second
+
 var DB *gorm.DB
 
 func ConnectDB() {
-	var err error
-	dns := os.Getenv("DNS")
+	db, err := Connect(config.App.Database)
+	if err != nil {
+		panic("Database connection failed: " + err.Error())
+	}
+	DB = db
+
+	// Report this pool on /metrics
+	if sqlDB, err := db.DB(); err == nil {
+		metrics.SetDB(sqlDB)
+	}
+}
+
+// CloseDB closes the connection pool, waiting for running queries to finish.
+func CloseDB() error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
 
-	DB, err = gorm.Open(mysql.Open(dns), &gorm.Config{})
+// Connect opens the database and configures its connection pool. A failed
+// attempt is retried cfg.ConnectRetries times, doubling the wait from
+// cfg.RetryBackoff each time, so the application can start before the
+// database is ready.
+func Connect(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	gormConfig := &gorm.Config{
+		Logger:         logging.NewGORMLogger(cfg.SlowQueryThreshold),
+		TranslateError: true, // Report constraint violations as gorm.ErrDuplicatedKey etc.
+	}
+	backoff := cfg.RetryBackoff
 
+	for attempt := 1; ; attempt++ {
+		db, err := open(cfg, gormConfig)
+		if err == nil {
+			return db, nil
+		}
+		if attempt > cfg.ConnectRetries {
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		slog.Warn("Database connection failed, retrying", "attempt", attempt, "backoff", backoff, "error", err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+// open makes a single connection attempt; gorm.Open pings the database.
+func open(cfg config.DatabaseConfig, gormConfig *gorm.Config) (*gorm.DB, error) {
+	dialector, err := Dialector(cfg)
 	if err != nil {
-		panic("Database connection failed!")
+		return nil, err
 	}
+
+	db, err := gorm.Open(dialector, gormConfig)
+	if err != nil {
+		// Release the pool opened before the failed ping
+		if db != nil {
+			if sqlDB, dbErr := db.DB(); dbErr == nil {
+				sqlDB.Close()
+			}
+		}
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	// Time every query for /metrics and trace it
+	for _, plugin := range []gorm.Plugin{metrics.GORMPlugin{}, tracing.GORMPlugin{}} {
+		if err := db.Use(plugin); err != nil {
+			sqlDB.Close()
+			return nil, err
+		}
+	}
+
+	return db, nil
+}
+
+// Dialector returns the GORM dialector for the configured driver:
+// "mysql", "postgres" or "sqlite" (pure Go, no cgo required).
+func Dialector(cfg config.DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case "mysql":
+		return mysql.Open(cfg.DSN), nil
+	case "postgres":
+		return postgres.Open(cfg.DSN), nil
+	case "sqlite":
+		return sqlite.Open(cfg.DSN), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
+}
+
+// PoolStats is a JSON view of the connection pool statistics.
+type PoolStats struct {
+	MaxOpenConnections int   `json:"max_open_connections"` // 0 means unlimited
+	OpenConnections    int   `json:"open_connections"`
+	InUse              int   `json:"in_use"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"wait_count"`       // Total connections waited for
+	WaitDurationMs     int64 `json:"wait_duration_ms"` // Total time blocked waiting
+	MaxIdleClosed      int64 `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64 `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64 `json:"max_lifetime_closed"`
+}
+
+// Stats returns the statistics of the connection pool of db.
+func Stats(db *gorm.DB) (PoolStats, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return PoolStats{}, err
+	}
+
+	stats := sqlDB.Stats()
+	return PoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDurationMs:     stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}, nil
 }
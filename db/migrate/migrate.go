@@ -1,26 +1,131 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
 	"github.com/Tokenzrey/FPPBKKGOLANG/config"
 	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
-	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
-	"log"
+	"github.com/Tokenzrey/FPPBKKGOLANG/db/migrations"
 )
 
-func init() {
-	config.LoadEnvVariables()
-	initializers.ConnectDB()
-}
+const usage = `Usage: go run ./db/migrate <command> [flags]
+
+Commands:
+  up [-steps N]           Apply pending migrations (all by default)
+  down [-steps N]         Revert the last N migrations (1 by default, 0 for all)
+  status                  List migrations and whether they are applied
+  create [-dir D] <name>  Write a new, empty migration file
+  fresh -force            Drop every table and apply all migrations (refused when APP_ENV=production)
+`
+
+// errUsage reports a command line that names no known command.
+var errUsage = errors.New("unknown command")
 
 func main() {
-	err := initializers.DB.Migrator().DropTable(models.User{}, models.Like{}, models.Blog{}, models.Comment{})
-	if err != nil {
-		log.Fatal("Table dropping failed")
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Exit only once run has returned, so that its deferred calls close the
+	// database and release the migration lock
+	if err := run(os.Args[1], os.Args[2:]); errors.Is(err, errUsage) {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	} else if err != nil {
+		log.Fatal(err)
 	}
+}
 
-	err = initializers.DB.AutoMigrate(models.User{}, models.Like{}, models.Blog{}, models.Comment{})
+// run executes command with its flags.
+func run(command string, args []string) error {
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	steps := flags.Int("steps", 0, "number of migrations")
+	dir := flags.String("dir", "db/migrations", "directory of the migration files")
+	force := flags.Bool("force", false, "confirm that all data may be destroyed")
+	if command == "down" {
+		*steps = 1
+	}
+	flags.Parse(args)
 
+	switch command {
+	case "create":
+		if flags.NArg() != 1 {
+			return errors.New("create needs exactly one migration name")
+		}
+		path, err := migrations.Create(*dir, flags.Arg(0))
+		if err != nil {
+			return fmt.Errorf("Creating migration failed: %w", err)
+		}
+		log.Println("Created " + path)
+		return nil
+	case "up", "down", "fresh", "status":
+	default:
+		return errUsage
+	}
+
+	config.Init()
+	if command == "fresh" {
+		if !*force {
+			return errors.New("fresh drops every table; run it again with -force to confirm")
+		}
+		if config.App.Env == "production" {
+			return errors.New("fresh is disabled when APP_ENV=production")
+		}
+	}
+	initializers.ConnectDB()
+	defer initializers.CloseDB()
+
+	ctx := context.Background()
+	migrator := migrations.New(initializers.DB)
+
+	switch command {
+	case "up":
+		return report(migrator.Up(ctx, *steps))
+	case "down":
+		return report(migrator.Down(ctx, *steps))
+	case "fresh":
+		return report(migrator.Fresh(ctx))
+	default:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("Reading migration status failed: %w", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+		for _, s := range statuses {
+			state := "pending"
+			switch {
+			case s.Missing:
+				state = "applied at " + s.AppliedAt.Format("2006-01-02 15:04:05") + " (missing from this build)"
+			case s.AppliedAt != nil:
+				state = "applied at " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, state)
+		}
+		return w.Flush()
+	}
+}
+
+// report prints the migrations that ran and returns the error that stopped
+// them.
+func report(done []migrations.Migration, err error) error {
+	for _, m := range done {
+		log.Printf("%04d_%s", m.Version, m.Name)
+	}
 	if err != nil {
-		log.Fatal("Migration failed")
+		return fmt.Errorf("Migration failed: %w", err)
+	}
+	if len(done) == 0 {
+		log.Println("Nothing to migrate")
 	}
+	return nil
 }
@@ -0,0 +1,66 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// The tables as they were created by AutoMigrate before versioned
+// migrations existed. These snapshots must not follow later model changes;
+// add a new migration instead. Running this migration against a database
+// created by the old migrate command only fills in what is missing.
+
+type userV1 struct {
+	gorm.Model
+	Name         string
+	Email        string `gorm:"unique;not null"`
+	Password     string
+	TanggalLahir string
+	Biografi     string
+}
+
+func (userV1) TableName() string { return "users" }
+
+type blogV1 struct {
+	gorm.Model
+	Judul     string
+	Content   string `gorm:"type:TEXT"`
+	Thumbnail string
+	UserID    uint
+	User      userV1 `gorm:"foreignKey:UserID;references:ID"`
+}
+
+func (blogV1) TableName() string { return "blogs" }
+
+type likeV1 struct {
+	gorm.Model
+	UserID uint
+	BlogID uint
+	User   userV1 `gorm:"foreignKey:UserID;references:ID"`
+	Blog   blogV1 `gorm:"foreignKey:BlogID;references:ID"`
+}
+
+func (likeV1) TableName() string { return "likes" }
+
+type commentV1 struct {
+	gorm.Model
+	Comment string
+	UserID  uint
+	BlogID  uint
+	User    userV1 `gorm:"foreignKey:UserID;references:ID"`
+	Blog    blogV1 `gorm:"foreignKey:BlogID;references:ID"`
+}
+
+func (commentV1) TableName() string { return "comments" }
+
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "create_tables",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&userV1{}, &blogV1{}, &likeV1{}, &commentV1{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&commentV1{}, &likeV1{}, &blogV1{}, &userV1{})
+		},
+	})
+}
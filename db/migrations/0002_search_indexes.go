@@ -0,0 +1,59 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// searchIndexes are the full-text indexes used by the SQL search backend.
+// SQLite has none and falls back to LIKE matching.
+var searchIndexes = []struct {
+	name, table, column string
+}{
+	{"ft_blogs_judul", "blogs", "judul"},
+	{"ft_blogs_content", "blogs", "content"},
+	{"ft_users_name", "users", "name"},
+}
+
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "search_indexes",
+		Up: func(tx *gorm.DB) error {
+			for _, index := range searchIndexes {
+				var statement string
+				switch tx.Dialector.Name() {
+				case "mysql":
+					if tx.Migrator().HasIndex(index.table, index.name) {
+						continue
+					}
+					statement = fmt.Sprintf("CREATE FULLTEXT INDEX %s ON %s (%s)", index.name, index.table, index.column)
+				case "postgres":
+					statement = fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s USING GIN (to_tsvector('simple', coalesce(%s, '')))", index.name, index.table, index.column)
+				default:
+					return nil
+				}
+
+				if err := tx.Exec(statement).Error; err != nil {
+					return fmt.Errorf("create index %s: %w", index.name, err)
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, index := range searchIndexes {
+				switch tx.Dialector.Name() {
+				case "mysql", "postgres":
+					if !tx.Migrator().HasIndex(index.table, index.name) {
+						continue
+					}
+					if err := tx.Migrator().DropIndex(index.table, index.name); err != nil {
+						return fmt.Errorf("drop index %s: %w", index.name, err)
+					}
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,132 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Likes and comments disappear with their blog or author (ON DELETE
+// CASCADE). Blogs keep restricting the deletion of their author.
+
+type likeV3 struct {
+	gorm.Model
+	UserID uint
+	BlogID uint
+	User   userV1 `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	Blog   blogV1 `gorm:"foreignKey:BlogID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (likeV3) TableName() string { return "likes" }
+
+type commentV3 struct {
+	gorm.Model
+	Comment string
+	UserID  uint
+	BlogID  uint
+	User    userV1 `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	Blog    blogV1 `gorm:"foreignKey:BlogID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (commentV3) TableName() string { return "comments" }
+
+// indexesV3 are portable CREATE INDEX statements keyed by index name.
+var indexesV3 = []struct {
+	name, table, statement string
+}{
+	{"idx_likes_user_blog", "likes", "CREATE UNIQUE INDEX idx_likes_user_blog ON likes (user_id, blog_id)"},
+	{"idx_likes_blog_id", "likes", "CREATE INDEX idx_likes_blog_id ON likes (blog_id)"},
+	{"idx_likes_created_at", "likes", "CREATE INDEX idx_likes_created_at ON likes (created_at)"},
+	{"idx_comments_blog_id", "comments", "CREATE INDEX idx_comments_blog_id ON comments (blog_id)"},
+	{"idx_comments_user_id", "comments", "CREATE INDEX idx_comments_user_id ON comments (user_id)"},
+	{"idx_comments_created_at", "comments", "CREATE INDEX idx_comments_created_at ON comments (created_at)"},
+	{"idx_blogs_user_id", "blogs", "CREATE INDEX idx_blogs_user_id ON blogs (user_id)"},
+	{"idx_blogs_created_at", "blogs", "CREATE INDEX idx_blogs_created_at ON blogs (created_at)"},
+}
+
+// deletedAtIndexes come from gorm.Model in 0001; they are recreated after
+// SQLite rebuilds a table.
+var deletedAtIndexes = []struct {
+	name, table, statement string
+}{
+	{"idx_likes_deleted_at", "likes", "CREATE INDEX idx_likes_deleted_at ON likes (deleted_at)"},
+	{"idx_comments_deleted_at", "comments", "CREATE INDEX idx_comments_deleted_at ON comments (deleted_at)"},
+}
+
+// createMissingIndexes runs the statements of the indexes that do not exist yet.
+func createMissingIndexes(tx *gorm.DB, indexes []struct{ name, table, statement string }) error {
+	for _, index := range indexes {
+		if tx.Migrator().HasIndex(index.table, index.name) {
+			continue
+		}
+		if err := tx.Exec(index.statement).Error; err != nil {
+			return fmt.Errorf("create index %s: %w", index.name, err)
+		}
+	}
+	return nil
+}
+
+// replaceConstraints drops the named foreign keys, if present, and creates them as declared on model.
+func replaceConstraints(tx *gorm.DB, model any, names ...string) error {
+	for _, name := range names {
+		if tx.Migrator().HasConstraint(model, name) {
+			if err := tx.Migrator().DropConstraint(model, name); err != nil {
+				return fmt.Errorf("drop constraint %s: %w", name, err)
+			}
+		}
+		if err := tx.Migrator().CreateConstraint(model, name); err != nil {
+			return fmt.Errorf("create constraint %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "like_comment_constraints",
+		Up: func(tx *gorm.DB) error {
+			// Unliking now deletes the row, so drop soft-deleted likes and
+			// keep the oldest of any duplicates before adding the unique index
+			if err := tx.Exec("DELETE FROM likes WHERE deleted_at IS NOT NULL").Error; err != nil {
+				return err
+			}
+			err := tx.Exec("DELETE FROM likes WHERE id NOT IN (SELECT id FROM (SELECT MIN(id) AS id FROM likes GROUP BY user_id, blog_id) AS keep)").Error
+			if err != nil {
+				return err
+			}
+
+			// SQLite rebuilds the table to change a constraint, losing its
+			// indexes, so the constraints come first
+			if err := replaceConstraints(tx, &likeV3{}, "User", "Blog"); err != nil {
+				return err
+			}
+			if err := replaceConstraints(tx, &commentV3{}, "User", "Blog"); err != nil {
+				return err
+			}
+			if err := createMissingIndexes(tx, deletedAtIndexes); err != nil {
+				return err
+			}
+			return createMissingIndexes(tx, indexesV3)
+		},
+		Down: func(tx *gorm.DB) error {
+			for i := len(indexesV3) - 1; i >= 0; i-- {
+				index := indexesV3[i]
+				if !tx.Migrator().HasIndex(index.table, index.name) {
+					continue
+				}
+				if err := tx.Migrator().DropIndex(index.table, index.name); err != nil {
+					return fmt.Errorf("drop index %s: %w", index.name, err)
+				}
+			}
+
+			if err := replaceConstraints(tx, &likeV1{}, "User", "Blog"); err != nil {
+				return err
+			}
+			if err := replaceConstraints(tx, &commentV1{}, "User", "Blog"); err != nil {
+				return err
+			}
+			return createMissingIndexes(tx, deletedAtIndexes)
+		},
+	})
+}
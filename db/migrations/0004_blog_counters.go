@@ -0,0 +1,66 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// blogV4 holds the columns added to blogs by this migration.
+type blogV4 struct {
+	ID           uint
+	LikeCount    int64 `gorm:"not null;default:0"`
+	CommentCount int64 `gorm:"not null;default:0"`
+}
+
+func (blogV4) TableName() string { return "blogs" }
+
+var counterIndexes = []struct {
+	name, table, statement string
+}{
+	{"idx_blogs_like_count", "blogs", "CREATE INDEX idx_blogs_like_count ON blogs (like_count)"},
+	{"idx_blogs_comment_count", "blogs", "CREATE INDEX idx_blogs_comment_count ON blogs (comment_count)"},
+}
+
+func init() {
+	register(Migration{
+		Version: 4,
+		Name:    "blog_counters",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range []string{"LikeCount", "CommentCount"} {
+				if tx.Migrator().HasColumn(&blogV4{}, column) {
+					continue
+				}
+				if err := tx.Migrator().AddColumn(&blogV4{}, column); err != nil {
+					return fmt.Errorf("add column %s: %w", column, err)
+				}
+			}
+
+			err := tx.Exec(`UPDATE blogs SET
+				like_count = (SELECT COUNT(*) FROM likes WHERE likes.blog_id = blogs.id AND likes.deleted_at IS NULL),
+				comment_count = (SELECT COUNT(*) FROM comments WHERE comments.blog_id = blogs.id AND comments.deleted_at IS NULL)`).Error
+			if err != nil {
+				return fmt.Errorf("backfill counters: %w", err)
+			}
+
+			return createMissingIndexes(tx, counterIndexes)
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, index := range counterIndexes {
+				if tx.Migrator().HasIndex(index.table, index.name) {
+					if err := tx.Migrator().DropIndex(index.table, index.name); err != nil {
+						return fmt.Errorf("drop index %s: %w", index.name, err)
+					}
+				}
+			}
+			// Plain ALTER TABLE works everywhere; GORM's SQLite migrator would
+			// rebuild the table, dropping its indexes and firing cascades
+			for _, column := range []string{"like_count", "comment_count"} {
+				if err := tx.Exec("ALTER TABLE blogs DROP COLUMN " + column).Error; err != nil {
+					return fmt.Errorf("drop column %s: %w", column, err)
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,57 @@
+package migrations
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// userV5 holds the columns added to users by this migration.
+type userV5 struct {
+	ID           uint
+	FailedLogins int `gorm:"not null;default:0"`
+	Lockouts     int `gorm:"not null;default:0"`
+	LockedUntil  *time.Time
+	LastLoginAt  *time.Time
+	LastLoginIP  string `gorm:"size:45"`
+}
+
+func (userV5) TableName() string { return "users" }
+
+var lockoutColumns = []struct {
+	field, column string
+}{
+	{"FailedLogins", "failed_logins"},
+	{"Lockouts", "lockouts"},
+	{"LockedUntil", "locked_until"},
+	{"LastLoginAt", "last_login_at"},
+	{"LastLoginIP", "last_login_ip"},
+}
+
+func init() {
+	register(Migration{
+		Version: 5,
+		Name:    "login_lockout",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range lockoutColumns {
+				if tx.Migrator().HasColumn(&userV5{}, column.field) {
+					continue
+				}
+				if err := tx.Migrator().AddColumn(&userV5{}, column.field); err != nil {
+					return fmt.Errorf("add column %s: %w", column.column, err)
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			// Plain ALTER TABLE, see blog_counters
+			for _, column := range lockoutColumns {
+				if err := tx.Exec("ALTER TABLE users DROP COLUMN " + column.column).Error; err != nil {
+					return fmt.Errorf("drop column %s: %w", column.column, err)
+				}
+			}
+			return nil
+		},
+	})
+}
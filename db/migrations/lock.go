@@ -0,0 +1,141 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrLocked is returned when another instance keeps the migration lock past the timeout.
+var ErrLocked = errors.New("another instance is migrating the database")
+
+const (
+	lockName  = "schema_migrations"      // MySQL named lock
+	lockKey   = 0x6d6967726174696f       // PostgreSQL advisory lock key ("migratio")
+	lockTable = "schema_migrations_lock" // Lock row for other databases
+	lockPoll  = 500 * time.Millisecond
+)
+
+// schemaLock is the single row of lockTable held while migrating.
+type schemaLock struct {
+	ID       int `gorm:"primaryKey;autoIncrement:false"`
+	Owner    string
+	LockedAt time.Time
+}
+
+func (schemaLock) TableName() string {
+	return lockTable
+}
+
+// withLock runs fn while holding the migration lock. MySQL and PostgreSQL
+// use session-level advisory locks, which the server releases if the process
+// dies; other databases insert a row into lockTable, which must be deleted
+// by hand if a migration crashes.
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	var (
+		unlock func()
+		err    error
+	)
+	switch m.db.Dialector.Name() {
+	case "mysql", "postgres":
+		unlock, err = m.advisoryLock(ctx)
+	default:
+		unlock, err = m.tableLock(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return fn()
+}
+
+// advisoryLock takes a session-level lock on a dedicated connection, which
+// is kept open until unlock.
+func (m *Migrator) advisoryLock(ctx context.Context) (func(), error) {
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock, release string
+	var args []any
+	if m.db.Dialector.Name() == "mysql" {
+		lock, release, args = "SELECT GET_LOCK(?, 0)", "SELECT RELEASE_LOCK(?)", []any{lockName}
+	} else {
+		lock, release, args = "SELECT pg_try_advisory_lock($1)", "SELECT pg_advisory_unlock($1)", []any{int64(lockKey)}
+	}
+
+	err = poll(ctx, m.LockTimeout, func() (bool, error) {
+		var acquired sql.NullBool
+		if err := conn.QueryRowContext(ctx, lock, args...).Scan(&acquired); err != nil {
+			return false, err
+		}
+		return acquired.Valid && acquired.Bool, nil
+	})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return func() {
+		conn.ExecContext(context.Background(), release, args...)
+		conn.Close()
+	}, nil
+}
+
+// tableLock inserts the single lock row; the primary key makes concurrent inserts fail.
+func (m *Migrator) tableLock(ctx context.Context) (func(), error) {
+	db := m.db.WithContext(ctx)
+	if err := db.AutoMigrate(&schemaLock{}); err != nil {
+		return nil, fmt.Errorf("create %s: %w", lockTable, err)
+	}
+
+	host, _ := os.Hostname()
+	owner := fmt.Sprintf("%s:%d", host, os.Getpid())
+	err := poll(ctx, m.LockTimeout, func() (bool, error) {
+		var existing int64
+		if err := db.Model(&schemaLock{}).Count(&existing).Error; err != nil {
+			return false, err
+		}
+		if existing > 0 {
+			return false, nil
+		}
+		// Losing a race with another instance is reported as a failed attempt
+		return db.Create(&schemaLock{ID: 1, Owner: owner, LockedAt: time.Now()}).Error == nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		m.db.Where("id = ? AND owner = ?", 1, owner).Delete(&schemaLock{})
+	}, nil
+}
+
+// poll calls try until it succeeds, fails, or timeout elapses.
+func poll(ctx context.Context, timeout time.Duration, try func() (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, err := try()
+		if err != nil || ok {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return ErrLocked
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockPoll):
+		}
+	}
+}
@@ -0,0 +1,276 @@
+// Package migrations holds the versioned schema migrations and runs them.
+//
+// Every migration lives in its own NNNN_name.go file and registers itself
+// from init. Applied versions are recorded in the schema_migrations table,
+// and a lock keeps two instances from migrating the same database at once.
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is one reversible schema change. Up and Down run inside a
+// transaction together with the bookkeeping in schema_migrations; MySQL
+// commits DDL statements implicitly, so keep each migration small there.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration is a row of the schema_migrations table.
+type SchemaMigration struct {
+	Version   int64  `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:255"`
+	AppliedAt time.Time
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status describes a migration and whether it is applied.
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time // Nil when pending
+	Missing   bool       // Applied in the database but unknown to this build
+}
+
+var registry []Migration
+
+// register adds a migration; it is called from the init of every migration file.
+func register(m Migration) {
+	for _, existing := range registry {
+		if existing.Version == m.Version {
+			panic(fmt.Sprintf("migration %04d registered twice (%s and %s)", m.Version, existing.Name, m.Name))
+		}
+	}
+	registry = append(registry, m)
+	slices.SortFunc(registry, func(a, b Migration) int { return int(a.Version - b.Version) })
+}
+
+// All returns the registered migrations, oldest first.
+func All() []Migration {
+	return slices.Clone(registry)
+}
+
+// Migrator applies and reverts migrations on a database.
+type Migrator struct {
+	db          *gorm.DB
+	migrations  []Migration
+	LockTimeout time.Duration // How long to wait for another instance to finish
+}
+
+// New creates a Migrator for the registered migrations.
+func New(db *gorm.DB) *Migrator {
+	return &Migrator{db: db, migrations: All(), LockTimeout: time.Minute}
+}
+
+// Up applies pending migrations, at most steps of them when steps > 0.
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func() error {
+		var err error
+		done, err = m.up(ctx, steps)
+		return err
+	})
+	return done, err
+}
+
+// Down reverts the steps most recently applied migrations; steps <= 0 reverts all of them.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func() error {
+		var err error
+		done, err = m.down(ctx, steps)
+		return err
+	})
+	return done, err
+}
+
+// Fresh drops every table, including ones no migration knows about, and
+// applies all migrations again. It destroys all data.
+func (m *Migrator) Fresh(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func() error {
+		tables, err := m.db.WithContext(ctx).Migrator().GetTables()
+		if err != nil {
+			return err
+		}
+		for _, table := range tables {
+			if table == lockTable || strings.HasPrefix(table, "sqlite_") {
+				continue // Keep the lock we hold and SQLite's internal tables
+			}
+			if err := m.db.WithContext(ctx).Migrator().DropTable(table); err != nil {
+				return fmt.Errorf("drop table %s: %w", table, err)
+			}
+		}
+
+		done, err = m.up(ctx, 0)
+		return err
+	})
+	return done, err
+}
+
+// Status lists every known migration, and applied ones missing from this build.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Status
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if row, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &row.AppliedAt
+			delete(applied, migration.Version)
+		}
+		result = append(result, status)
+	}
+	for _, row := range applied {
+		result = append(result, Status{Version: row.Version, Name: row.Name, AppliedAt: &row.AppliedAt, Missing: true})
+	}
+	slices.SortFunc(result, func(a, b Status) int { return int(a.Version - b.Version) })
+
+	return result, nil
+}
+
+// up applies pending migrations; the caller must hold the lock.
+func (m *Migrator) up(ctx context.Context, steps int) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if steps > 0 && len(done) == steps {
+			break
+		}
+
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %04d_%s up: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// down reverts applied migrations, newest first; the caller must hold the lock.
+func (m *Migrator) down(ctx context.Context, steps int) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if steps > 0 && len(done) == steps {
+			break
+		}
+
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, migration.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %04d_%s down: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// applied returns the rows of schema_migrations keyed by version, creating the table if needed.
+func (m *Migrator) applied(ctx context.Context) (map[int64]SchemaMigration, error) {
+	db := m.db.WithContext(ctx)
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var rows []SchemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]SchemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+var nonWord = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create writes an empty migration file to dir, numbered after the latest
+// registered migration, and returns its path.
+func Create(dir, name string) (string, error) {
+	slug := strings.Trim(nonWord.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if slug == "" {
+		return "", errors.New("migration name must contain letters or digits")
+	}
+
+	var version int64 = 1
+	if len(registry) > 0 {
+		version = registry[len(registry)-1].Version + 1
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%04d_%s.go", version, slug))
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintf(file, template, version, slug); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+const template = `package migrations
+
+import "gorm.io/gorm"
+
+func init() {
+	register(Migration{
+		Version: %d,
+		Name:    %q,
+		Up: func(tx *gorm.DB) error {
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	})
+}
+`
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"log"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/config"
+	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/counters"
+)
+
+// Recomputes the like and comment counters of every blog from the likes
+// and comments tables. Safe to run at any time.
+func main() {
+	log.SetFlags(0)
+
+	config.Init()
+	initializers.ConnectDB()
+	defer initializers.CloseDB()
+
+	fixed, err := counters.Reconcile(initializers.DB)
+	if err != nil {
+		log.Fatal("Reconciling counters failed: ", err)
+	}
+	log.Printf("Fixed the counters of %d blogs", fixed)
+}
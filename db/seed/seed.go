@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/config"
+	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
+	"github.com/Tokenzrey/FPPBKKGOLANG/db/seeds"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/storage"
+)
+
+func main() {
+	log.SetFlags(0)
+
+	opts := seeds.DefaultOptions()
+	flag.IntVar(&opts.Users, "users", opts.Users, "number of users")
+	flag.IntVar(&opts.Blogs, "blogs", opts.Blogs, "number of blogs")
+	flag.IntVar(&opts.Likes, "likes", opts.Likes, "number of likes")
+	flag.IntVar(&opts.Comments, "comments", opts.Comments, "number of comments")
+	flag.IntVar(&opts.Thumbnails, "thumbnails", opts.Thumbnails, "number of distinct generated thumbnails")
+	flag.Int64Var(&opts.Seed, "seed", opts.Seed, "random seed; the same seed generates the same data")
+	flag.StringVar(&opts.Password, "password", opts.Password, "password of every seeded user")
+	flag.Parse()
+
+	config.Init()
+	if config.App.Env == "production" {
+		log.Fatal("Seeding is disabled when APP_ENV=production")
+	}
+	initializers.ConnectDB()
+	defer initializers.CloseDB()
+	storage.Init(config.App.Storage)
+
+	summary, err := seeds.Run(context.Background(), initializers.DB, storage.Default, opts)
+	if err != nil {
+		log.Fatal("Seeding failed: ", err)
+	}
+
+	log.Printf("Created %d users, %d blogs, %d likes and %d comments", summary.Users, summary.Blogs, summary.Likes, summary.Comments)
+	log.Printf("Log in as %s … %s with password %q", seeds.Email(1), seeds.Email(opts.Users), opts.Password)
+}
@@ -0,0 +1,267 @@
+// Package seeds fills a migrated database with realistic fake data for
+// local development: users with a known password, blogs with generated
+// thumbnails, likes and comments.
+package seeds
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/counters"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/imaging"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/storage"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Options sets how much data is generated.
+type Options struct {
+	Users      int
+	Blogs      int
+	Likes      int // Capped at Users*Blogs, since a user likes a blog once
+	Comments   int
+	Thumbnails int   // Distinct generated images shared by the blogs
+	Seed       int64 // The same seed generates the same data
+	Password   string
+}
+
+// DefaultOptions returns a small data set that exercises pagination and sorting.
+func DefaultOptions() Options {
+	return Options{
+		Users:      10,
+		Blogs:      60,
+		Likes:      300,
+		Comments:   200,
+		Thumbnails: 8,
+		Seed:       1,
+		Password:   "password123",
+	}
+}
+
+// Summary counts what Run created.
+type Summary struct {
+	Users    int
+	Blogs    int
+	Likes    int
+	Comments int
+}
+
+// Email returns the address of the i-th seeded user, starting at 1.
+func Email(i int) string {
+	return fmt.Sprintf("user%d@example.com", i)
+}
+
+// Run generates the data set. Seeded users are reused when their email
+// already exists, so running it again only adds blogs, likes and comments.
+// Likes and comments favour a few blogs so sorting by them is meaningful.
+func Run(ctx context.Context, db *gorm.DB, store storage.Storage, opts Options) (Summary, error) {
+	var summary Summary
+	if opts.Users <= 0 || opts.Blogs < 0 || opts.Likes < 0 || opts.Comments < 0 {
+		return summary, fmt.Errorf("at least one user is required and volumes must not be negative")
+	}
+	r := rand.New(rand.NewSource(opts.Seed))
+
+	thumbnails, err := storeThumbnails(ctx, store, r, max(opts.Thumbnails, 1))
+	if err != nil {
+		return summary, err
+	}
+
+	// Every user shares the known password, so hash it once
+	hashed, err := bcrypt.GenerateFromPassword([]byte(opts.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return summary, err
+	}
+
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		users := make([]models.User, opts.Users)
+		for i := range users {
+			name := pick(r, firstNames) + " " + pick(r, lastNames)
+			users[i] = models.User{
+				Name:         name,
+				Email:        Email(i + 1),
+				Password:     string(hashed),
+				TanggalLahir: time.Date(1980+r.Intn(25), time.Month(1+r.Intn(12)), 1+r.Intn(28), 0, 0, 0, 0, time.UTC).Format("2006-01-02"),
+				Biografi:     sentence(r, 8+r.Intn(10)),
+			}
+			result := tx.Where(models.User{Email: users[i].Email}).FirstOrCreate(&users[i])
+			if result.Error != nil {
+				return result.Error
+			}
+			summary.Users += int(result.RowsAffected)
+		}
+
+		// Spread the blogs over the last 90 days
+		now := time.Now()
+		blogs := make([]models.Blog, opts.Blogs)
+		for i := range blogs {
+			created := now.Add(-time.Duration(r.Int63n(int64(90 * 24 * time.Hour))))
+			blogs[i] = models.Blog{
+				Judul:     title(r),
+				Content:   paragraphs(r, 2+r.Intn(4)),
+				Thumbnail: thumbnails[r.Intn(len(thumbnails))],
+				UserID:    users[r.Intn(len(users))].ID,
+			}
+			blogs[i].CreatedAt, blogs[i].UpdatedAt = created, created
+		}
+		if len(blogs) > 0 {
+			if err := tx.CreateInBatches(&blogs, 100).Error; err != nil {
+				return err
+			}
+		}
+		summary.Blogs = len(blogs)
+		if len(blogs) == 0 {
+			return nil
+		}
+
+		popular := popularity(r, len(blogs))
+
+		// Likes are unique per user and blog
+		likes := make([]models.Like, 0, min(opts.Likes, len(users)*len(blogs)))
+		seen := make(map[[2]int]bool)
+		for attempts := 0; len(likes) < cap(likes) && attempts < opts.Likes*20; attempts++ {
+			u, b := r.Intn(len(users)), popular()
+			if seen[[2]int{u, b}] {
+				continue
+			}
+			seen[[2]int{u, b}] = true
+			likes = append(likes, models.Like{UserID: users[u].ID, BlogID: blogs[b].ID})
+		}
+		if len(likes) > 0 {
+			if err := tx.CreateInBatches(&likes, 500).Error; err != nil {
+				return err
+			}
+		}
+		summary.Likes = len(likes)
+
+		comments := make([]models.Comment, opts.Comments)
+		for i := range comments {
+			comments[i] = models.Comment{
+				Comment: sentence(r, 4+r.Intn(14)),
+				UserID:  users[r.Intn(len(users))].ID,
+				BlogID:  blogs[popular()].ID,
+			}
+		}
+		if len(comments) > 0 {
+			if err := tx.CreateInBatches(&comments, 500).Error; err != nil {
+				return err
+			}
+		}
+		summary.Comments = len(comments)
+
+		_, err := counters.Reconcile(tx)
+		return err
+	})
+
+	return summary, err
+}
+
+// popularity returns a generator of blog indexes following a Zipf
+// distribution over a shuffled order, so a few blogs get most interactions.
+func popularity(r *rand.Rand, n int) func() int {
+	order := r.Perm(n)
+	if n == 1 {
+		return func() int { return 0 }
+	}
+	zipf := rand.NewZipf(r, 1.1, 2, uint64(n-1))
+	return func() int { return order[zipf.Uint64()] }
+}
+
+// storeThumbnails generates n gradient images and stores them with their
+// resized variants under content-hash names, like uploads. It returns the
+// file names.
+func storeThumbnails(ctx context.Context, store storage.Storage, r *rand.Rand, n int) ([]string, error) {
+	names := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, gradient(r, 1280, 720), &jpeg.Options{Quality: imaging.JPEGQuality}); err != nil {
+			return nil, err
+		}
+
+		processed, err := imaging.Process(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("process thumbnail: %w", err)
+		}
+
+		hash := sha256.Sum256(processed.Original)
+		fileName := hex.EncodeToString(hash[:]) + processed.Extension()
+		files := map[string][]byte{fileName: processed.Original}
+		for width, variant := range processed.Variants {
+			files[imaging.VariantName(fileName, width)] = variant
+		}
+		for name, content := range files {
+			if err := store.Put(ctx, name, bytes.NewReader(content), processed.ContentType()); err != nil {
+				return nil, fmt.Errorf("store thumbnail %s: %w", name, err)
+			}
+		}
+		names = append(names, fileName)
+	}
+	return names, nil
+}
+
+// gradient draws a diagonal blend between two random colours.
+func gradient(r *rand.Rand, width, height int) image.Image {
+	from := color.RGBA{uint8(r.Intn(256)), uint8(r.Intn(256)), uint8(r.Intn(256)), 255}
+	to := color.RGBA{uint8(r.Intn(256)), uint8(r.Intn(256)), uint8(r.Intn(256)), 255}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			t := float64(x+y) / float64(width+height-2)
+			img.Set(x, y, color.RGBA{
+				uint8(float64(from.R) + t*(float64(to.R)-float64(from.R))),
+				uint8(float64(from.G) + t*(float64(to.G)-float64(from.G))),
+				uint8(float64(from.B) + t*(float64(to.B)-float64(from.B))),
+				255,
+			})
+		}
+	}
+	return img
+}
+
+var (
+	firstNames = []string{"Andi", "Budi", "Citra", "Dewi", "Eka", "Fajar", "Gita", "Hadi", "Indah", "Joko", "Kartika", "Lestari", "Made", "Nadia", "Putu", "Rizky", "Sari", "Teguh", "Wulan", "Yusuf"}
+	lastNames  = []string{"Pratama", "Saputra", "Wijaya", "Santoso", "Hidayat", "Kusuma", "Nugroho", "Lestari", "Siregar", "Wibowo"}
+	topics     = []string{"Golang", "Gin", "GORM", "PostgreSQL", "MySQL", "Docker", "Kubernetes", "REST API", "JWT", "Redis", "Microservices", "Testing", "Concurrency", "Clean Architecture"}
+	patterns   = []string{"Belajar %s untuk Pemula", "Tips Menggunakan %s di Produksi", "Memahami %s dengan Mudah", "Getting Started with %s", "%s Best Practices", "Kesalahan Umum Saat Memakai %s", "Deep Dive into %s", "Membangun Aplikasi dengan %s"}
+	words      = strings.Fields("aplikasi server database query index performa golang gin gorm handler middleware token pengguna blog komentar like cache deploy container konfigurasi logging error testing integrasi fitur data request response api backend frontend skalabilitas keamanan the a is with for and to build run fast simple reliable service module package function interface struct")
+)
+
+func pick(r *rand.Rand, from []string) string {
+	return from[r.Intn(len(from))]
+}
+
+func title(r *rand.Rand) string {
+	return fmt.Sprintf(pick(r, patterns), pick(r, topics))
+}
+
+// sentence returns n random words, capitalised and ending with a full stop.
+func sentence(r *rand.Rand, n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = pick(r, words)
+	}
+	s := strings.Join(parts, " ")
+	return strings.ToUpper(s[:1]) + s[1:] + "."
+}
+
+func paragraphs(r *rand.Rand, n int) string {
+	result := make([]string, n)
+	for i := range result {
+		sentences := make([]string, 3+r.Intn(4))
+		for j := range sentences {
+			sentences[j] = sentence(r, 6+r.Intn(12))
+		}
+		result[i] = strings.Join(sentences, " ")
+	}
+	return strings.Join(result, "\n\n")
+}
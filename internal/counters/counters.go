@@ -0,0 +1,38 @@
+// Package counters maintains the like and comment counts stored on blogs.
+// They are adjusted in the same transaction as the row they count, and
+// Reconcile recomputes them from the likes and comments tables.
+package counters
+
+import (
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"gorm.io/gorm"
+)
+
+// AdjustLikes adds delta to the like count of a blog.
+func AdjustLikes(tx *gorm.DB, blogID uint, delta int64) error {
+	return adjust(tx, blogID, "like_count", delta)
+}
+
+// AdjustComments adds delta to the comment count of a blog.
+func AdjustComments(tx *gorm.DB, blogID uint, delta int64) error {
+	return adjust(tx, blogID, "comment_count", delta)
+}
+
+func adjust(tx *gorm.DB, blogID uint, column string, delta int64) error {
+	// UpdateColumn leaves updated_at alone: a like does not edit the blog
+	return tx.Model(&models.Blog{}).Unscoped().Where("id = ?", blogID).
+		UpdateColumn(column, gorm.Expr(column+" + ?", delta)).Error
+}
+
+// Reconcile recomputes every blog's counters from the source rows and
+// returns how many blogs had drifted.
+func Reconcile(db *gorm.DB) (int64, error) {
+	const (
+		likes    = "(SELECT COUNT(*) FROM likes WHERE likes.blog_id = blogs.id AND likes.deleted_at IS NULL)"
+		comments = "(SELECT COUNT(*) FROM comments WHERE comments.blog_id = blogs.id AND comments.deleted_at IS NULL)"
+	)
+
+	result := db.Exec("UPDATE blogs SET like_count = " + likes + ", comment_count = " + comments +
+		" WHERE like_count <> " + likes + " OR comment_count <> " + comments)
+	return result.RowsAffected, result.Error
+}
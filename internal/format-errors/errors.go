@@ -1,32 +1,152 @@
 package format_errors
 
 import (
+	"encoding/json"
 	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/helpers"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/repository"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
-	"net/http"
 )
 
-func RecordNotFound(c *gin.Context, err error, errMessage ...string) {
-	errorMessage := "The record not found"
-	if len(errMessage) > 0 {
-		errorMessage = errMessage[0]
+// ProblemJSON is the media type of RFC 7807 problem details.
+const ProblemJSON = "application/problem+json"
+
+// Error is an error returned by a handler, with the status and the code
+// clients see. Its message is shown to them; the cause is not.
+type Error struct {
+	Status     int
+	Code       helpers.ErrorCode
+	Message    string
+	Fields     map[string][]string // Messages of each invalid field
+	RetryAfter time.Duration       // When a rate limited client may retry
+	Err        error               // Cause, for the logs
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
 	}
+	return e.Message
+}
 
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": errorMessage,
-		})
-		return
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New creates an Error with a specific code, e.g. CodeInvalidImage.
+func New(status int, code helpers.ErrorCode, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// BadRequest reports a malformed request.
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, helpers.CodeBadRequest, message)
+}
+
+// Validation reports invalid fields with their messages.
+func Validation(message string, fields map[string][]string) *Error {
+	err := New(http.StatusUnprocessableEntity, helpers.CodeValidation, message)
+	err.Fields = fields
+	return err
+}
+
+// Unauthorized reports a missing or invalid token.
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, helpers.CodeUnauthorized, message)
+}
+
+// Forbidden reports an action the user may not take.
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, helpers.CodeForbidden, message)
+}
+
+// NotFound reports a missing resource.
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, helpers.CodeNotFound, message)
+}
+
+// Conflict reports a request clashing with the current state.
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, helpers.CodeConflict, message)
+}
+
+// RateLimited reports too many requests; retryAfter is sent in Retry-After.
+func RateLimited(message string, retryAfter time.Duration) *Error {
+	err := New(http.StatusTooManyRequests, helpers.CodeRateLimited, message)
+	err.RetryAfter = retryAfter
+	return err
+}
+
+// Internal reports a failure of the server caused by err.
+func Internal(message string, err error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: helpers.CodeInternal, Message: message, Err: err}
+}
+
+// From converts any error to an Error: missing records become 404s,
+// transactions lost to concurrent ones 409s and unknown errors 500s.
+func From(err error) *Error {
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, repository.ErrNotFound):
+		e = NotFound("The record not found")
+		e.Err = err
+		return e
+	case errors.Is(err, repository.ErrConflict):
+		e = Conflict("Concurrent requests got in the way, try again")
+		e.Err = err
+		return e
+	default:
+		return Internal("Internal server error", err)
 	}
+}
 
-	// Else show internal server error
-	InternalServerError(c)
+// Problem is an RFC 7807 problem details document, extended with the error
+// code and the invalid fields.
+type Problem struct {
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Status   int                 `json:"status"`
+	Detail   string              `json:"detail"`
+	Instance string              `json:"instance"`
+	Code     helpers.ErrorCode   `json:"code"`
+	Errors   map[string][]string `json:"errors,omitempty"`
 }
 
-func InternalServerError(c *gin.Context) {
-	c.JSON(http.StatusInternalServerError, gin.H{
-		"error": "Internal server error",
+// Render sends err as problem+json when the client accepts it before plain
+// JSON, otherwise in the API envelope, and aborts the remaining handlers.
+func Render(c *gin.Context, err error) {
+	e := From(err)
+	if e.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int((e.RetryAfter+time.Second-1)/time.Second)))
+	}
+
+	if c.NegotiateFormat(gin.MIMEJSON, ProblemJSON) != ProblemJSON {
+		c.AbortWithStatusJSON(e.Status, helpers.Envelope[any]{
+			Status:  "error",
+			Message: e.Message,
+			Code:    e.Code,
+			Errors:  e.Fields,
+		})
+		return
+	}
+
+	body, _ := json.Marshal(Problem{
+		Type:     "/problems/" + strings.ToLower(strings.ReplaceAll(string(e.Code), "_", "-")),
+		Title:    http.StatusText(e.Status),
+		Status:   e.Status,
+		Detail:   e.Message,
+		Instance: c.Request.URL.Path,
+		Code:     e.Code,
+		Errors:   e.Fields,
 	})
-	return
+	c.Data(e.Status, ProblemJSON, body)
+	c.Abort()
 }
@@ -0,0 +1,78 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Timeout bounds how long a single check may take.
+const Timeout = 2 * time.Second
+
+// Check probes one dependency of the application.
+type Check struct {
+	Name     string
+	Required bool // When false, a failure is reported without failing readiness
+	Run      func(ctx context.Context) error
+}
+
+// Status is the outcome of a check.
+type Status struct {
+	Status    string `json:"status"` // "up" or "down"
+	Required  bool   `json:"required"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// draining is set once the server starts shutting down.
+var draining atomic.Bool
+
+// SetDraining marks the server as shutting down, which makes it unready.
+func SetDraining(value bool) {
+	draining.Store(value)
+}
+
+// Draining reports whether the server is shutting down.
+func Draining() bool {
+	return draining.Load()
+}
+
+// Run executes the checks concurrently, each within Timeout, and reports
+// whether every required check succeeded.
+func Run(ctx context.Context, checks []Check) (map[string]Status, bool) {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]Status, len(checks))
+		ready   = true
+	)
+
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, Timeout)
+			defer cancel()
+			start := time.Now()
+			err := check.Run(checkCtx)
+
+			status := Status{Status: "up", Required: check.Required, LatencyMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				status.Status = "down"
+				status.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[check.Name] = status
+			if err != nil && check.Required {
+				ready = false
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	return results, ready
+}
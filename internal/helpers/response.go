@@ -3,30 +3,97 @@ package helpers
 import (
 	"net/http"
 
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/pagination"
 	"github.com/gin-gonic/gin"
 )
 
+// Envelope is the shape of every API response. Data is typed by the
+// handler; Code is set on errors, Errors on validation failures and Meta
+// on paginated lists.
+type Envelope[T any] struct {
+	Status  string              `json:"status"`           // "success" or "error"
+	Data    T                   `json:"data"`             // Data payload (null if error)
+	Message string              `json:"message"`          // Description of the response
+	Code    ErrorCode           `json:"code,omitempty"`   // Machine-readable error code
+	Errors  map[string][]string `json:"errors,omitempty"` // Messages of each invalid field
+	Meta    *Meta               `json:"meta,omitempty"`   // Pagination of a list
+}
+
 // Response struct for standardized API response
-type APIResponse struct {
-	Status  string      `json:"status"`  // "success" or "error"
-	Data    interface{} `json:"data"`    // Data payload (null if error)
-	Message string      `json:"message"` // Description of the response
+type APIResponse = Envelope[any]
+
+// Meta describes the page of a paginated list.
+type Meta struct {
+	CurrentPage int   `json:"current_page"` // The current page number
+	From        int   `json:"from"`         // The starting record number for the current page
+	To          int   `json:"to"`           // The ending record number for the current page
+	LastPage    int   `json:"last_page"`    // The total number of pages
+	PerPage     int   `json:"per_page"`     // The number of records per page
+	Total       int64 `json:"total"`        // The total number of records
 }
 
-// Helper function to send success response
-func SuccessResponse(c *gin.Context, data interface{}, message string) {
-	c.JSON(http.StatusOK, APIResponse{
+// ErrorCode identifies an error for clients independently of its message.
+type ErrorCode string
+
+const (
+	CodeBadRequest         ErrorCode = "BAD_REQUEST"
+	CodeValidation         ErrorCode = "VALIDATION_FAILED"
+	CodeUnauthorized       ErrorCode = "UNAUTHORIZED"
+	CodeInvalidCredentials ErrorCode = "INVALID_CREDENTIALS"
+	CodeForbidden          ErrorCode = "FORBIDDEN"
+	CodeNotFound           ErrorCode = "NOT_FOUND"
+	CodeRouteNotFound      ErrorCode = "ROUTE_NOT_FOUND"
+	CodeConflict           ErrorCode = "CONFLICT"
+	CodeInvalidImage       ErrorCode = "INVALID_IMAGE"
+	CodeFileTooLarge       ErrorCode = "FILE_TOO_LARGE"
+	CodeRateLimited        ErrorCode = "RATE_LIMITED"
+	CodeInternal           ErrorCode = "INTERNAL_ERROR"
+	CodeUnavailable        ErrorCode = "SERVICE_UNAVAILABLE"
+)
+
+// Success sends data with a 2xx status.
+func Success[T any](c *gin.Context, statusCode int, data T, message string) {
+	c.JSON(statusCode, Envelope[T]{
 		Status:  "success",
 		Data:    data,
 		Message: message,
 	})
 }
 
-// Helper function to send error response
-func ErrorResponse(c *gin.Context, statusCode int, message string) {
-	c.JSON(statusCode, APIResponse{
+// Created sends a newly created resource with 201 Created.
+func Created[T any](c *gin.Context, data T, message string) {
+	Success(c, http.StatusCreated, data, message)
+}
+
+// Paginated sends the records of a page as data and its position as meta.
+func Paginated(c *gin.Context, page pagination.PaginateResult, message string) {
+	c.JSON(http.StatusOK, Envelope[any]{
+		Status:  "success",
+		Data:    page.Data,
+		Message: message,
+		Meta: &Meta{
+			CurrentPage: page.CurrentPage,
+			From:        page.From,
+			To:          page.To,
+			LastPage:    page.LastPage,
+			PerPage:     page.PerPage,
+			Total:       page.Total,
+		},
+	})
+}
+
+// FailWithData sends an error with details, such as the failed health
+// checks. Handlers return the errors of format_errors instead.
+func FailWithData[T any](c *gin.Context, statusCode int, code ErrorCode, message string, data T) {
+	c.AbortWithStatusJSON(statusCode, Envelope[T]{
 		Status:  "error",
-		Data:    nil,
+		Data:    data,
 		Message: message,
+		Code:    code,
 	})
-}
\ No newline at end of file
+}
+
+// Helper function to send success response
+func SuccessResponse(c *gin.Context, data interface{}, message string) {
+	Success(c, http.StatusOK, data, message)
+}
@@ -0,0 +1,145 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// Widths lists the pixel widths generated for every uploaded thumbnail.
+var Widths = []int{320, 768, 1280}
+
+// JPEGQuality is the quality used when re-encoding JPEG images.
+const JPEGQuality = 85
+
+// ErrUnsupportedFormat is returned when the image is not a JPEG, PNG, GIF or WebP.
+var ErrUnsupportedFormat = errors.New("unsupported image format")
+
+// Result holds the processed image and its resized variants.
+type Result struct {
+	Format   string         // Output format: "jpeg" or "png"
+	Original []byte         // Full-size image, upright and without metadata
+	Variants map[int][]byte // Resized images keyed by width
+}
+
+// Extension returns the file extension matching the output format.
+func (r *Result) Extension() string {
+	if r.Format == "jpeg" {
+		return ".jpg"
+	}
+	return "." + r.Format
+}
+
+// ContentType returns the MIME type matching the output format.
+func (r *Result) ContentType() string {
+	return "image/" + r.Format
+}
+
+// Process validates and decodes an uploaded JPEG, PNG, GIF or WebP image,
+// applies its EXIF orientation and re-encodes it, which drops all metadata
+// (including GPS). A resized copy is generated for every width in Widths;
+// images are never upscaled.
+//
+// JPEG images stay JPEG. Everything else is stored as PNG: there is no WebP
+// encoder in the standard library, and only the first frame of an animated
+// GIF is kept.
+func Process(data []byte) (*Result, error) {
+	format, err := validate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	// Only JPEG files carry EXIF orientation
+	output := "png"
+	if format == "jpeg" {
+		img = applyOrientation(img, readOrientation(data))
+		output = "jpeg"
+	}
+
+	original, err := encode(img, output)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Format:   output,
+		Original: original,
+		Variants: make(map[int][]byte, len(Widths)),
+	}
+
+	for _, width := range Widths {
+		encoded, err := encode(resize(img, width), output)
+		if err != nil {
+			return nil, err
+		}
+		result.Variants[width] = encoded
+	}
+
+	return result, nil
+}
+
+// resize scales img down to the given width, keeping the aspect ratio.
+func resize(img image.Image, width int) image.Image {
+	b := img.Bounds()
+	if b.Dx() <= width {
+		return img
+	}
+
+	height := b.Dy() * width / b.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// encode writes img in the given format without any metadata.
+func encode(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: JPEGQuality})
+	case "png":
+		err = png.Encode(&buf, img)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encode image: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// VariantName returns the file name used for the given width of fileName,
+// e.g. "123.jpg" becomes "123_320.jpg".
+func VariantName(fileName string, width int) string {
+	ext := filepath.Ext(fileName)
+	return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(fileName, ext), width, ext)
+}
+
+// SrcSet returns a srcset-style map ("320w" => URL) for a stored thumbnail,
+// using urlFor to turn a file name into a public URL.
+func SrcSet(fileName string, urlFor func(name string) string) map[string]string {
+	srcSet := make(map[string]string, len(Widths))
+	for _, width := range Widths {
+		srcSet[fmt.Sprintf("%dw", width)] = urlFor(VariantName(fileName, width))
+	}
+	return srcSet
+}
@@ -0,0 +1,89 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"net/http"
+
+	_ "image/gif"  // Register the GIF decoder
+	_ "image/jpeg" // Register the JPEG decoder
+	_ "image/png"  // Register the PNG decoder
+
+	_ "golang.org/x/image/webp" // Register the WebP decoder
+)
+
+// Limits protecting the decoder against decompression bombs.
+const (
+	MaxDimension = 8000       // Maximum width or height in pixels
+	MaxPixels    = 40_000_000 // Maximum width * height
+)
+
+var (
+	// ErrInvalidImage is returned when the file does not decode cleanly as the detected format.
+	ErrInvalidImage = errors.New("invalid or corrupted image")
+	// ErrTooLarge is returned when the image dimensions exceed MaxDimension or MaxPixels.
+	ErrTooLarge = errors.New("image dimensions are too large")
+)
+
+// mimeFormats maps the sniffed content type to the decoder name registered in package image.
+var mimeFormats = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+// validate sniffs the content type of data and checks that the header agrees
+// with it, that the dimensions are within limits and that nothing is appended
+// after the end of the image. It returns the detected format without
+// decoding any pixels.
+func validate(data []byte) (string, error) {
+	sniffed, ok := mimeFormats[http.DetectContentType(data)]
+	if !ok {
+		return "", ErrUnsupportedFormat
+	}
+
+	// Read only the header to learn the dimensions before allocating pixels
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || format != sniffed {
+		return "", ErrInvalidImage
+	}
+
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return "", ErrInvalidImage
+	}
+	if cfg.Width > MaxDimension || cfg.Height > MaxDimension || cfg.Width*cfg.Height > MaxPixels {
+		return "", ErrTooLarge
+	}
+
+	// Reject polyglots: the file must end where the image ends
+	if !hasValidTrailer(format, data) {
+		return "", ErrInvalidImage
+	}
+
+	return format, nil
+}
+
+// hasValidTrailer reports whether data ends with the end marker of its format.
+func hasValidTrailer(format string, data []byte) bool {
+	switch format {
+	case "jpeg":
+		// Some encoders pad the file with zero bytes after the EOI marker
+		return bytes.HasSuffix(bytes.TrimRight(data, "\x00"), []byte{0xFF, 0xD9})
+	case "png":
+		// Zero-length IEND chunk followed by its fixed CRC
+		return bytes.HasSuffix(data, []byte{0, 0, 0, 0, 'I', 'E', 'N', 'D', 0xAE, 0x42, 0x60, 0x82})
+	case "gif":
+		return bytes.HasSuffix(data, []byte{0x3B})
+	case "webp":
+		// The RIFF header stores the size of everything after the first 8 bytes
+		if len(data) < 12 {
+			return false
+		}
+		size := int(binary.LittleEndian.Uint32(data[4:8])) + 8
+		return size == len(data) || size+1 == len(data) // Allow the RIFF pad byte
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// GORMLogger writes the log of GORM through the logger of the query's
+// context, so its lines carry the request and trace IDs like the others.
+// Failed queries are errors and queries slower than SlowThreshold warnings;
+// with LogMode(logger.Info), as set by DB.Debug, every query is logged.
+// The SQL keeps its placeholders, never the values bound to them.
+type GORMLogger struct {
+	SlowThreshold time.Duration // Zero disables the slow-query log
+	Level         logger.LogLevel
+}
+
+// NewGORMLogger returns a GORMLogger logging failed and slow queries.
+func NewGORMLogger(slowThreshold time.Duration) *GORMLogger {
+	return &GORMLogger{SlowThreshold: slowThreshold, Level: logger.Warn}
+}
+
+func (l *GORMLogger) LogMode(level logger.LogLevel) logger.Interface {
+	copied := *l
+	copied.Level = level
+	return &copied
+}
+
+func (l *GORMLogger) Info(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, logger.Info, slog.LevelInfo, fmt.Sprintf(msg, args...))
+}
+
+func (l *GORMLogger) Warn(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, logger.Warn, slog.LevelWarn, fmt.Sprintf(msg, args...))
+}
+
+func (l *GORMLogger) Error(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, logger.Error, slog.LevelError, fmt.Sprintf(msg, args...))
+}
+
+// Trace logs a query that failed, was slow or, at logger.Info, any query.
+// Missing records are not failures.
+func (l *GORMLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		l.log(ctx, logger.Error, slog.LevelError, "query failed", append(queryAttrs(fc, elapsed), "error", err)...)
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold:
+		l.log(ctx, logger.Warn, slog.LevelWarn, "slow query", append(queryAttrs(fc, elapsed), "threshold", l.SlowThreshold)...)
+	default:
+		l.log(ctx, logger.Info, slog.LevelInfo, "query", queryAttrs(fc, elapsed)...)
+	}
+}
+
+// ParamsFilter leaves the values out of the SQL given to Trace, as they
+// may be personal data or password hashes.
+func (l *GORMLogger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	return sql, nil
+}
+
+// log writes msg at level if GORM logs at least at gormLevel.
+func (l *GORMLogger) log(ctx context.Context, gormLevel logger.LogLevel, level slog.Level, msg string, args ...any) {
+	if l.Level < gormLevel {
+		return
+	}
+	FromContext(ctx).Log(ctx, level, msg, append(args, "source", utils.FileWithLineNum())...)
+}
+
+// queryAttrs returns the attributes of a traced query.
+func queryAttrs(fc func() (string, int64), elapsed time.Duration) []any {
+	sql, rows := fc()
+	args := []any{"sql", sql, "duration", elapsed}
+	if rows >= 0 { // -1 when unknown
+		args = append(args, "rows", rows)
+	}
+	return args
+}
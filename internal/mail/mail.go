@@ -0,0 +1,154 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/config"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/logging"
+)
+
+// sendTimeout bounds an SMTP exchange whose context has no deadline.
+const sendTimeout = 10 * time.Second
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers emails, such as the notice of a locked account.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Default is the sender used by the application.
+var Default Sender
+
+// Init creates the sender selected by cfg.Driver ("log" or "smtp") and
+// assigns it to Default.
+func Init(cfg config.MailConfig) {
+	var err error
+	Default, err = New(cfg)
+	if err != nil {
+		panic("Mail initialization failed: " + err.Error())
+	}
+}
+
+// New creates the sender described by cfg.
+func New(cfg config.MailConfig) (Sender, error) {
+	switch cfg.Driver {
+	case "log":
+		return Log{}, nil
+	case "smtp":
+		return NewSMTP(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
+
+// Log writes the emails to the log instead of sending them, for development.
+type Log struct{}
+
+func (Log) Send(ctx context.Context, msg Message) error {
+	logging.FromContext(ctx).Info("Email", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
+
+// SMTP sends the emails through an SMTP server, with STARTTLS when the
+// server offers it.
+type SMTP struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTP creates a sender for the server of cfg, authenticating when a
+// username is set.
+func NewSMTP(cfg config.MailConfig) *SMTP {
+	s := &SMTP{addr: net.JoinHostPort(cfg.SMTPHost, cfg.SMTPPort), from: cfg.From}
+	if cfg.SMTPUsername != "" {
+		s.auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+	return s
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	// Header injection: the addresses and subject must stay on one line
+	for _, value := range []string{msg.To, msg.Subject} {
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("mail: line break in header %q", value)
+		}
+	}
+
+	body := strings.Join([]string{
+		"From: " + s.from,
+		"To: " + msg.To,
+		"Subject: " + msg.Subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		msg.Body,
+	}, "\r\n")
+
+	if err := s.send(ctx, msg.To, []byte(body)); err != nil {
+		return fmt.Errorf("mail: sending to %s: %w", msg.To, err)
+	}
+	logging.FromContext(ctx).Debug("Email sent", "to", msg.To, "subject", msg.Subject)
+	return nil
+}
+
+// send runs the SMTP exchange within the deadline of ctx, or sendTimeout.
+func (s *SMTP) send(ctx context.Context, to string, body []byte) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(sendTimeout)
+	}
+	conn.SetDeadline(deadline)
+
+	host, _, _ := net.SplitHostPort(s.addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.auth != nil {
+		if err := c.Auth(s.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
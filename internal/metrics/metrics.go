@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds every metric of the application, plus the Go runtime and
+// process metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts the finished requests by route template and status.
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route template and status.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPDuration observes how long the requests took.
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route template and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// DBQueryDuration observes the GORM operations by kind and table.
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Database query latency by operation and table.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	// UploadSize observes the size of the uploaded thumbnails, refused ones included.
+	UploadSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "upload_size_bytes",
+		Help:    "Size of uploaded files.",
+		Buckets: prometheus.ExponentialBuckets(16*1024, 2, 9), // 16 KiB to 4 MiB
+	})
+
+	Signups = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "signups_total",
+		Help: "Users signed up.",
+	})
+	Logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "logins_total",
+		Help: "Login attempts by result: succeeded, failed or locked.",
+	}, []string{"result"})
+	BlogsPosted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "blogs_posted_total",
+		Help: "Blogs published.",
+	})
+	LikesToggled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "likes_toggled_total",
+		Help: "Likes toggled by action: liked or unliked.",
+	}, []string{"action"})
+	CommentsPosted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "comments_posted_total",
+		Help: "Comments posted.",
+	})
+	RateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rate_limited_requests_total",
+		Help: "Requests refused by the rate limit of a route group.",
+	}, []string{"group"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests, HTTPDuration, DBQueryDuration, UploadSize,
+		Signups, Logins, BlogsPosted, LikesToggled, CommentsPosted, RateLimited,
+		pool,
+	)
+
+	// Export the labelled counters at zero before the first event
+	Logins.WithLabelValues("succeeded")
+	Logins.WithLabelValues("failed")
+	Logins.WithLabelValues("locked")
+	LikesToggled.WithLabelValues("liked")
+	LikesToggled.WithLabelValues("unliked")
+	RateLimited.WithLabelValues("auth")
+	RateLimited.WithLabelValues("write")
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"database/sql"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// pool reports the statistics of the database connection pool set with
+// SetDB at every scrape.
+var pool = &poolCollector{
+	maxOpen:      prometheus.NewDesc("db_pool_max_open_connections", "Maximum number of open connections, 0 for unlimited.", nil, nil),
+	open:         prometheus.NewDesc("db_pool_open_connections", "Established connections, in use or idle.", nil, nil),
+	inUse:        prometheus.NewDesc("db_pool_in_use_connections", "Connections in use.", nil, nil),
+	idle:         prometheus.NewDesc("db_pool_idle_connections", "Idle connections.", nil, nil),
+	waitCount:    prometheus.NewDesc("db_pool_wait_count_total", "Connections waited for.", nil, nil),
+	waitDuration: prometheus.NewDesc("db_pool_wait_duration_seconds_total", "Time blocked waiting for a connection.", nil, nil),
+}
+
+type poolCollector struct {
+	db                                                  atomic.Pointer[sql.DB]
+	maxOpen, open, inUse, idle, waitCount, waitDuration *prometheus.Desc
+}
+
+// SetDB makes the pool gauges report db.
+func SetDB(db *sql.DB) {
+	pool.db.Store(db)
+}
+
+func (p *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{p.maxOpen, p.open, p.inUse, p.idle, p.waitCount, p.waitDuration} {
+		ch <- desc
+	}
+}
+
+func (p *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	db := p.db.Load()
+	if db == nil {
+		return
+	}
+	stats := db.Stats()
+	ch <- prometheus.MustNewConstMetric(p.maxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(p.open, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(p.inUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(p.idle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(p.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(p.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+}
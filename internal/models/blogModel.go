@@ -8,9 +8,11 @@ type Blog struct {
 	Content   string `json:"content" gorm:"type:TEXT"`
 	Thumbnail string `json:"thumbnail"`
 
-	// Virtual fields (not stored in DB)
-	LikeCount    int64 `json:"like_count" gorm:"-"`
-	CommentCount int64 `json:"comment_count" gorm:"-"`
-	UserID       uint  `json:"user_id"`
-	User         User  `gorm:"foreignKey:UserID;references:ID"`
+	// Counters kept in step with the likes and comments tables (see internal/counters)
+	LikeCount    int64 `json:"like_count" gorm:"not null;default:0"`
+	CommentCount int64 `json:"comment_count" gorm:"not null;default:0"`
+
+	Relevance float64 `json:"-" gorm:"->;-:migration"` // Search score, only selected by search queries
+	UserID    uint    `json:"user_id"`
+	User      User    `gorm:"foreignKey:UserID;references:ID"`
 }
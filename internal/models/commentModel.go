@@ -8,6 +8,6 @@ type Comment struct {
     UserID uint `json:"user_id"`
     BlogID uint `json:"blog_id"`
 
-    User User `gorm:"foreignKey:UserID;references:ID"`
-    Blog Blog `gorm:"foreignKey:BlogID;references:ID"`
+    User User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+    Blog Blog `gorm:"foreignKey:BlogID;references:ID;constraint:OnDelete:CASCADE"`
 }
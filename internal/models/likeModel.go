@@ -2,11 +2,13 @@ package models
 
 import "gorm.io/gorm"
 
+// Like is unique per user and blog; unliking deletes the row for good.
+// The indexes and foreign keys are created by migration 0003.
 type Like struct {
     gorm.Model
-    UserID uint `json:"user_id"` // Foreign key untuk User
-    BlogID uint `json:"blog_id"` // Foreign key untuk Blog
+    UserID uint `json:"user_id" gorm:"uniqueIndex:idx_likes_user_blog"` // Foreign key untuk User
+    BlogID uint `json:"blog_id" gorm:"uniqueIndex:idx_likes_user_blog"` // Foreign key untuk Blog
 
-    User User `gorm:"foreignKey:UserID;references:ID"`
-    Blog Blog `gorm:"foreignKey:BlogID;references:ID"`
+    User User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+    Blog Blog `gorm:"foreignKey:BlogID;references:ID;constraint:OnDelete:CASCADE"`
 }
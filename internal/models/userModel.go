@@ -1,6 +1,10 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	gorm.Model
@@ -9,4 +13,12 @@ type User struct {
 	Password string `json:"-"`
 	TanggalLahir string `json:"tanggal_lahir"`
 	Biografi string `json:"biografi"`
+
+	// Login state: failures since the last success or lockout, consecutive
+	// lockouts, and the last successful login
+	FailedLogins int        `json:"-"`
+	Lockouts     int        `json:"-"`
+	LockedUntil  *time.Time `json:"-"`
+	LastLoginAt  *time.Time `json:"-"`
+	LastLoginIP  string     `json:"-"`
 }
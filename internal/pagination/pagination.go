@@ -54,20 +54,38 @@ func Paginate(db *gorm.DB, page, limit int, rawFunc func(*gorm.DB) *gorm.DB, out
 		return PaginateResult{}, err
 	}
 
+	// Return the paginated result
+	return NewResult(output, page, limit, total), nil
+}
+
+// NewResult builds the pagination metadata for a page of data.
+//
+// @Description This function is used by Paginate and by callers that page
+// through data themselves (e.g. an in-memory search index).
+//
+// @param data interface{} - The data for the current page
+// @param page int - The current page number (1-based index)
+// @param limit int - The maximum number of records per page
+// @param total int64 - The total number of records
+//
+// @return PaginateResult - A struct containing the data and metadata
+func NewResult(data interface{}, page, limit int, total int64) PaginateResult {
+	// Calculate the offset for the current page
+	offset := (page - 1) * limit
+
 	// Calculate the ending record number for the current page
 	to := offset + limit
 	if to > int(total) {
 		to = int(total) // Adjust if the total records are less than the limit
 	}
 
-	// Return the paginated result
 	return PaginateResult{
-		Data:        output,                  // The data for the current page
+		Data:        data,                    // The data for the current page
 		CurrentPage: page,                    // The current page number
 		From:        offset + 1,              // The starting record number
 		To:          to,                      // The ending record number
 		LastPage:    (int(total) + limit - 1) / limit, // Total pages (ceil(total/limit))
 		PerPage:     limit,                   // Records per page
 		Total:       total,                   // Total records
-	}, nil
+	}
 }
@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// Limit is a token bucket holding up to Requests tokens, refilled at
+// Requests per Period. Each request takes a token, so a client may burst
+// Requests requests and then continue at the refill rate.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// rate returns the tokens added per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Result is the state of a bucket after taking a token.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int           // Whole tokens left
+	Reset      time.Duration // Until the bucket is full again
+	RetryAfter time.Duration // Until the next token when not allowed
+}
+
+// result describes a bucket of limit holding tokens after a request.
+func result(limit Limit, allowed bool, tokens float64) Result {
+	rate := limit.rate()
+	r := Result{
+		Allowed:   allowed,
+		Limit:     limit.Requests,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(limit.Requests) - tokens) / rate),
+	}
+	if !allowed {
+		r.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return r
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Store keeps the token buckets. The memory store serves one instance;
+// instances sharing their limits use the Redis store.
+type Store interface {
+	// Take takes a token from the bucket of key, created full when missing.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Limiter applies a limit to the requests of a route group.
+type Limiter struct {
+	Name  string // Prefix of the keys, unique per group
+	Limit Limit
+	Store Store
+}
+
+// Take takes a token from the bucket of key, e.g. a client IP.
+func (l *Limiter) Take(ctx context.Context, key string) (Result, error) {
+	return l.Store.Take(ctx, l.Name+":"+key, l.Limit)
+}
+
+// Groups are the limiters of the route groups; a nil limiter does not limit.
+type Groups struct {
+	Auth  *Limiter // Signup and login, by client IP
+	Write *Limiter // Publishing, liking and commenting, by user
+}
+
+// Default is the limiters used by the application, none until Init.
+var Default Groups
+
+// Init creates the limiters configured by cfg in the store it selects
+// ("memory" or "redis") and assigns them to Default.
+func Init(cfg config.RateLimitConfig) {
+	var err error
+	Default, err = New(cfg)
+	if err != nil {
+		panic("Rate limit initialization failed: " + err.Error())
+	}
+}
+
+// New creates the limiters configured by cfg.
+func New(cfg config.RateLimitConfig) (Groups, error) {
+	var store Store
+	switch cfg.Store {
+	case "memory":
+		store = NewMemory()
+	case "redis":
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return Groups{}, err
+		}
+		store = NewRedis(redis.NewClient(opts), "ratelimit:")
+	default:
+		return Groups{}, fmt.Errorf("unknown rate limit store %q", cfg.Store)
+	}
+
+	auth, err := limiter("auth", cfg.Auth, store)
+	if err != nil {
+		return Groups{}, err
+	}
+	write, err := limiter("write", cfg.Write, store)
+	if err != nil {
+		return Groups{}, err
+	}
+	return Groups{Auth: auth, Write: write}, nil
+}
+
+// limiter creates the limiter of a group, nil when its rate is "0".
+func limiter(name, rate string, store Store) (*Limiter, error) {
+	requests, period, err := config.ParseRate(rate)
+	if err != nil || requests == 0 {
+		return nil, err
+	}
+	return &Limiter{Name: name, Limit: Limit{Requests: requests, Period: period}, Store: store}, nil
+}
@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/counters"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/pagination"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// transactionAttempts bounds how often a transaction aborted by a deadlock
+// is run.
+const transactionAttempts = 3
+
+// NewGORM returns repositories backed by db. The connection must translate
+// errors (gorm.Config.TranslateError) for ErrDuplicate to be reported.
+func NewGORM(db *gorm.DB) Repositories {
+	return Repositories{
+		Users:    &gormUsers{db: db},
+		Blogs:    &gormBlogs{db: db},
+		Likes:    &gormLikes{db: db},
+		Comments: &gormComments{db: db},
+	}
+}
+
+// translate maps GORM errors to the errors of this package.
+func translate(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrDuplicate
+	case aborted(err):
+		return ErrConflict
+	default:
+		return err
+	}
+}
+
+// aborted reports whether the database rolled back a transaction to break a
+// deadlock or a serialization failure, so that running it again may succeed:
+// MySQL error 1213 or PostgreSQL SQLSTATE 40001 and 40P01.
+func aborted(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1213
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+	return false
+}
+
+// transaction runs fn in a transaction, running it again when the database
+// aborts it in favour of a concurrent one. Under REPEATABLE READ, MySQL takes
+// gap locks that can deadlock two requests writing the same rows.
+func transaction(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	var err error
+	for range transactionAttempts {
+		if err = db.WithContext(ctx).Transaction(fn); !aborted(err) {
+			break
+		}
+	}
+	return translate(err)
+}
+
+type gormUsers struct {
+	db *gorm.DB
+}
+
+func (r *gormUsers) Create(ctx context.Context, user *models.User) error {
+	return translate(r.db.WithContext(ctx).Create(user).Error)
+}
+
+func (r *gormUsers) FindByID(ctx context.Context, id uint) (models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).First(&user, id).Error
+	return user, translate(err)
+}
+
+func (r *gormUsers) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).First(&user, "email = ?", email).Error
+	return user, translate(err)
+}
+
+func (r *gormUsers) Update(ctx context.Context, user *models.User) error {
+	return translate(r.db.WithContext(ctx).Save(user).Error)
+}
+
+func (r *gormUsers) UpdateLoginState(ctx context.Context, user *models.User) error {
+	// Columns rather than a struct so that zero values are written too,
+	// and updated_at keeps the time of the last profile change
+	err := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", user.ID).UpdateColumns(map[string]any{
+		"failed_logins": user.FailedLogins,
+		"lockouts":      user.Lockouts,
+		"locked_until":  user.LockedUntil,
+		"last_login_at": user.LastLoginAt,
+		"last_login_ip": user.LastLoginIP,
+	}).Error
+	return translate(err)
+}
+
+// RecordLoginFailure increments the counter in the database rather than
+// writing back a count read earlier, and locks with a conditional UPDATE
+// that only the first failure past the threshold matches.
+func (r *gormUsers) RecordLoginFailure(ctx context.Context, id uint, now time.Time, threshold int, lockFor func(lockouts int) time.Duration) (models.User, bool, error) {
+	db := r.db.WithContext(ctx).Model(&models.User{})
+	err := db.Where("id = ? AND (locked_until IS NULL OR locked_until <= ?)", id, now).
+		UpdateColumn("failed_logins", gorm.Expr("failed_logins + 1")).Error
+	if err != nil {
+		return models.User{}, false, translate(err)
+	}
+	user, err := r.FindByID(ctx, id)
+	if err != nil || user.FailedLogins < threshold {
+		return user, false, err
+	}
+
+	// A concurrent failure locking the account first resets the count and
+	// adds a lockout, and this UPDATE then matches nothing
+	result := db.Where("id = ? AND failed_logins >= ? AND lockouts = ?", id, threshold, user.Lockouts).UpdateColumns(map[string]any{
+		"failed_logins": 0,
+		"lockouts":      user.Lockouts + 1,
+		"locked_until":  now.Add(lockFor(user.Lockouts)),
+	})
+	if result.Error != nil {
+		return user, false, translate(result.Error)
+	}
+	user, err = r.FindByID(ctx, id)
+	return user, result.RowsAffected > 0, err
+}
+
+type gormBlogs struct {
+	db *gorm.DB
+}
+
+func (r *gormBlogs) Create(ctx context.Context, blog *models.Blog) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Create(blog).Error; err != nil {
+		return translate(err)
+	}
+	return translate(db.First(&blog.User, blog.UserID).Error)
+}
+
+func (r *gormBlogs) FindByID(ctx context.Context, id uint) (models.Blog, error) {
+	var blog models.Blog
+	err := r.db.WithContext(ctx).Preload("User").First(&blog, id).Error
+	return blog, translate(err)
+}
+
+func (r *gormBlogs) List(ctx context.Context, opts ListOptions) (pagination.PaginateResult, error) {
+	var blogs []models.Blog
+
+	// The stored counters are indexed, so no per-row subquery is needed
+	rawFunc := func(db *gorm.DB) *gorm.DB {
+		query := db.Preload("User")
+
+		switch opts.Sort {
+		case SortLikes:
+			return query.Order("blogs.like_count DESC").Order("blogs.created_at DESC")
+		case SortComments:
+			return query.Order("blogs.comment_count DESC").Order("blogs.created_at DESC")
+		default:
+			return query.Order("blogs.created_at DESC")
+		}
+	}
+
+	return pagination.Paginate(r.db.WithContext(ctx), opts.Page, opts.PerPage, rawFunc, &blogs)
+}
+
+func (r *gormBlogs) ListByUser(ctx context.Context, userID uint) ([]models.Blog, error) {
+	var blogs []models.Blog
+	err := r.db.WithContext(ctx).Preload("User").Where("user_id = ?", userID).Find(&blogs).Error
+	return blogs, err
+}
+
+// Delete removes the row for good rather than setting deleted_at, so that
+// the foreign keys delete the likes and comments of the blog (ON DELETE
+// CASCADE).
+func (r *gormBlogs) Delete(ctx context.Context, blog models.Blog) error {
+	return r.db.WithContext(ctx).Unscoped().Delete(&blog).Error
+}
+
+func (r *gormBlogs) CountByThumbnail(ctx context.Context, thumbnail string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Blog{}).Where("thumbnail = ?", thumbnail).Count(&count).Error
+	return count, err
+}
+
+type gormLikes struct {
+	db *gorm.DB
+}
+
+// Toggle relies on a single DELETE to tell whether the like existed, and on
+// the unique index on (user_id, blog_id) to settle concurrent requests.
+// Concurrent first likes may deadlock on the gap locks of the DELETE, and
+// the one the database aborts is run again.
+func (r *gormLikes) Toggle(ctx context.Context, userID, blogID uint) (bool, error) {
+	var liked bool
+	err := transaction(ctx, r.db, func(tx *gorm.DB) error {
+		liked = false
+		result := tx.Unscoped().Where("user_id = ? AND blog_id = ?", userID, blogID).Delete(&models.Like{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			return counters.AdjustLikes(tx, blogID, -1)
+		}
+
+		if err := tx.Create(&models.Like{UserID: userID, BlogID: blogID}).Error; err != nil {
+			return err
+		}
+		liked = true
+		return counters.AdjustLikes(tx, blogID, 1)
+	})
+	return liked, err
+}
+
+func (r *gormLikes) Exists(ctx context.Context, userID, blogID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Like{}).Where("user_id = ? AND blog_id = ?", userID, blogID).Count(&count).Error
+	return count > 0, err
+}
+
+type gormComments struct {
+	db *gorm.DB
+}
+
+func (r *gormComments) Create(ctx context.Context, comment *models.Comment) error {
+	return transaction(ctx, r.db, func(tx *gorm.DB) error {
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		return counters.AdjustComments(tx, comment.BlogID, 1)
+	})
+}
+
+func (r *gormComments) FindByID(ctx context.Context, id uint) (models.Comment, error) {
+	var comment models.Comment
+	err := r.db.WithContext(ctx).First(&comment, id).Error
+	return comment, translate(err)
+}
+
+func (r *gormComments) Delete(ctx context.Context, comment models.Comment) error {
+	return transaction(ctx, r.db, func(tx *gorm.DB) error {
+		result := tx.Delete(&comment)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error // Nothing to uncount if a concurrent request deleted it first
+		}
+		return counters.AdjustComments(tx, comment.BlogID, -1)
+	})
+}
+
+func (r *gormComments) ListByBlog(ctx context.Context, blogID uint) ([]models.Comment, error) {
+	var comments []models.Comment
+	err := r.db.WithContext(ctx).Preload("User").Where("blog_id = ?", blogID).
+		Order("created_at DESC").Order("id DESC").Find(&comments).Error
+	return comments, err
+}
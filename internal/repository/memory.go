@@ -0,0 +1,352 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/pagination"
+)
+
+// NewMemory returns in-memory repositories for tests. They share one store,
+// so likes and comments update the counters of the blogs they belong to
+// like the database does. Deleted records are removed outright.
+func NewMemory() Repositories {
+	store := &memoryStore{
+		users:    make(map[uint]models.User),
+		blogs:    make(map[uint]models.Blog),
+		likes:    make(map[[2]uint]models.Like),
+		comments: make(map[uint]models.Comment),
+	}
+	return Repositories{
+		Users:    &memoryUsers{store},
+		Blogs:    &memoryBlogs{store},
+		Likes:    &memoryLikes{store},
+		Comments: &memoryComments{store},
+	}
+}
+
+type memoryStore struct {
+	mu       sync.Mutex
+	lastID   uint
+	users    map[uint]models.User
+	blogs    map[uint]models.Blog
+	likes    map[[2]uint]models.Like // Keyed by user and blog ID
+	comments map[uint]models.Comment
+}
+
+// nextID numbers records like an auto-increment column; the caller must hold mu.
+func (s *memoryStore) nextID() uint {
+	s.lastID++
+	return s.lastID
+}
+
+// blog returns a stored blog with its User loaded; the caller must hold mu.
+func (s *memoryStore) blog(id uint) (models.Blog, bool) {
+	blog, ok := s.blogs[id]
+	blog.User = s.users[blog.UserID]
+	return blog, ok
+}
+
+type memoryUsers struct {
+	*memoryStore
+}
+
+func (r *memoryUsers) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return ErrDuplicate
+		}
+	}
+	user.ID = r.nextID()
+	user.CreatedAt, user.UpdatedAt = time.Now(), time.Now()
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *memoryUsers) FindByID(ctx context.Context, id uint) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+func (r *memoryUsers) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return models.User{}, ErrNotFound
+}
+
+func (r *memoryUsers) Update(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.ID]; !ok {
+		return ErrNotFound
+	}
+	for _, existing := range r.users {
+		if existing.ID != user.ID && existing.Email == user.Email {
+			return ErrDuplicate
+		}
+	}
+	user.UpdatedAt = time.Now()
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *memoryUsers) UpdateLoginState(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.FailedLogins = user.FailedLogins
+	stored.Lockouts = user.Lockouts
+	stored.LockedUntil = user.LockedUntil
+	stored.LastLoginAt = user.LastLoginAt
+	stored.LastLoginIP = user.LastLoginIP
+	r.users[user.ID] = stored
+	return nil
+}
+
+func (r *memoryUsers) RecordLoginFailure(ctx context.Context, id uint, now time.Time, threshold int, lockFor func(lockouts int) time.Duration) (models.User, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return models.User{}, false, ErrNotFound
+	}
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		return user, false, nil
+	}
+	user.FailedLogins++
+	locked := user.FailedLogins >= threshold
+	if locked {
+		until := now.Add(lockFor(user.Lockouts))
+		user.FailedLogins = 0
+		user.Lockouts++
+		user.LockedUntil = &until
+	}
+	r.users[id] = user
+	return user, locked, nil
+}
+
+type memoryBlogs struct {
+	*memoryStore
+}
+
+func (r *memoryBlogs) Create(ctx context.Context, blog *models.Blog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	blog.ID = r.nextID()
+	blog.CreatedAt, blog.UpdatedAt = time.Now(), time.Now()
+	blog.User = models.User{}
+	r.blogs[blog.ID] = *blog
+	blog.User = r.users[blog.UserID]
+	return nil
+}
+
+func (r *memoryBlogs) FindByID(ctx context.Context, id uint) (models.Blog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	blog, ok := r.blog(id)
+	if !ok {
+		return models.Blog{}, ErrNotFound
+	}
+	return blog, nil
+}
+
+func (r *memoryBlogs) List(ctx context.Context, opts ListOptions) (pagination.PaginateResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	all := make([]models.Blog, 0, len(r.blogs))
+	for id := range r.blogs {
+		blog, _ := r.blog(id)
+		all = append(all, blog)
+	}
+
+	slices.SortFunc(all, func(a, b models.Blog) int {
+		switch {
+		case opts.Sort == SortLikes && a.LikeCount != b.LikeCount:
+			return int(b.LikeCount - a.LikeCount)
+		case opts.Sort == SortComments && a.CommentCount != b.CommentCount:
+			return int(b.CommentCount - a.CommentCount)
+		}
+		if order := b.CreatedAt.Compare(a.CreatedAt); order != 0 {
+			return order
+		}
+		return int(b.ID) - int(a.ID)
+	})
+
+	from := min((opts.Page-1)*opts.PerPage, len(all))
+	to := min(from+opts.PerPage, len(all))
+	page := all[from:to]
+	return pagination.NewResult(&page, opts.Page, opts.PerPage, int64(len(all))), nil
+}
+
+func (r *memoryBlogs) ListByUser(ctx context.Context, userID uint) ([]models.Blog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var blogs []models.Blog
+	for id, blog := range r.blogs {
+		if blog.UserID == userID {
+			blog, _ = r.blog(id)
+			blogs = append(blogs, blog)
+		}
+	}
+	slices.SortFunc(blogs, func(a, b models.Blog) int { return int(a.ID) - int(b.ID) })
+	return blogs, nil
+}
+
+func (r *memoryBlogs) Delete(ctx context.Context, blog models.Blog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Like the foreign keys, which cascade to the likes and comments
+	delete(r.blogs, blog.ID)
+	for key, like := range r.likes {
+		if like.BlogID == blog.ID {
+			delete(r.likes, key)
+		}
+	}
+	for id, comment := range r.comments {
+		if comment.BlogID == blog.ID {
+			delete(r.comments, id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryBlogs) CountByThumbnail(ctx context.Context, thumbnail string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int64
+	for _, blog := range r.blogs {
+		if blog.Thumbnail == thumbnail {
+			count++
+		}
+	}
+	return count, nil
+}
+
+type memoryLikes struct {
+	*memoryStore
+}
+
+func (r *memoryLikes) Toggle(ctx context.Context, userID, blogID uint) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	blog, ok := r.blogs[blogID]
+	if !ok {
+		return false, ErrNotFound // The foreign key refuses likes of missing blogs
+	}
+
+	key := [2]uint{userID, blogID}
+	_, liked := r.likes[key]
+	if liked {
+		delete(r.likes, key)
+		blog.LikeCount--
+	} else {
+		like := models.Like{UserID: userID, BlogID: blogID}
+		like.ID = r.nextID()
+		like.CreatedAt, like.UpdatedAt = time.Now(), time.Now()
+		r.likes[key] = like
+		blog.LikeCount++
+	}
+	r.blogs[blogID] = blog
+	return !liked, nil
+}
+
+func (r *memoryLikes) Exists(ctx context.Context, userID, blogID uint) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.likes[[2]uint{userID, blogID}]
+	return ok, nil
+}
+
+type memoryComments struct {
+	*memoryStore
+}
+
+func (r *memoryComments) Create(ctx context.Context, comment *models.Comment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	blog, ok := r.blogs[comment.BlogID]
+	if !ok {
+		return ErrNotFound
+	}
+	comment.ID = r.nextID()
+	comment.CreatedAt, comment.UpdatedAt = time.Now(), time.Now()
+	r.comments[comment.ID] = *comment
+	blog.CommentCount++
+	r.blogs[blog.ID] = blog
+	return nil
+}
+
+func (r *memoryComments) FindByID(ctx context.Context, id uint) (models.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	comment, ok := r.comments[id]
+	if !ok {
+		return models.Comment{}, ErrNotFound
+	}
+	return comment, nil
+}
+
+func (r *memoryComments) Delete(ctx context.Context, comment models.Comment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.comments[comment.ID]; !ok {
+		return nil
+	}
+	delete(r.comments, comment.ID)
+	if blog, ok := r.blogs[comment.BlogID]; ok {
+		blog.CommentCount--
+		r.blogs[blog.ID] = blog
+	}
+	return nil
+}
+
+func (r *memoryComments) ListByBlog(ctx context.Context, blogID uint) ([]models.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var comments []models.Comment
+	for _, comment := range r.comments {
+		if comment.BlogID == blogID {
+			comment.User = r.users[comment.UserID]
+			comments = append(comments, comment)
+		}
+	}
+	// IDs grow with creation time, so the newest comment has the highest ID
+	slices.SortFunc(comments, func(a, b models.Comment) int { return int(b.ID) - int(a.ID) })
+	return comments, nil
+}
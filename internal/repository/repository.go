@@ -0,0 +1,101 @@
+// Package repository is the data access layer. Each aggregate (users,
+// blogs, likes and comments) has an interface with a GORM implementation
+// for the application and an in-memory fake for tests.
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/pagination"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist.
+	ErrNotFound = errors.New("record not found")
+	// ErrDuplicate is returned when a unique constraint refuses a record.
+	ErrDuplicate = errors.New("duplicate record")
+	// ErrConflict is returned when the database kept aborting a transaction
+	// to break a deadlock with concurrent ones.
+	ErrConflict = errors.New("transaction aborted by concurrent ones")
+)
+
+// Sort orders of BlogRepository.List.
+const (
+	SortNewest   = ""
+	SortLikes    = "likes"
+	SortComments = "comments"
+)
+
+// ListOptions selects a page of blogs.
+type ListOptions struct {
+	Page    int
+	PerPage int
+	Sort    string // SortNewest, SortLikes or SortComments
+}
+
+// UserRepository stores users.
+type UserRepository interface {
+	// Create inserts a user; ErrDuplicate means the email is taken.
+	Create(ctx context.Context, user *models.User) error
+	FindByID(ctx context.Context, id uint) (models.User, error)
+	FindByEmail(ctx context.Context, email string) (models.User, error)
+	// Update saves every field of an existing user.
+	Update(ctx context.Context, user *models.User) error
+	// UpdateLoginState saves only the failed logins, lockout and last login
+	// of a user, leaving a concurrent profile update alone.
+	UpdateLoginState(ctx context.Context, user *models.User) error
+	// RecordLoginFailure counts a failed login of a user at now in the
+	// store, so that concurrent failures all count; failures while the
+	// account is locked do not. Once the count reaches threshold, it is
+	// reset and the account locked for lockFor(lockouts so far). locked
+	// reports whether this failure locked it, which only one of concurrent
+	// failures does. It returns the user as stored afterwards, or ErrNotFound
+	// for an unknown id.
+	RecordLoginFailure(ctx context.Context, id uint, now time.Time, threshold int, lockFor func(lockouts int) time.Duration) (user models.User, locked bool, err error)
+}
+
+// BlogRepository stores blogs. Blogs are returned with their User loaded.
+type BlogRepository interface {
+	Create(ctx context.Context, blog *models.Blog) error
+	FindByID(ctx context.Context, id uint) (models.Blog, error)
+	// List returns a page of blogs whose Data is a *[]models.Blog.
+	List(ctx context.Context, opts ListOptions) (pagination.PaginateResult, error)
+	ListByUser(ctx context.Context, userID uint) ([]models.Blog, error)
+	// Delete removes a blog with its likes and comments.
+	Delete(ctx context.Context, blog models.Blog) error
+	// CountByThumbnail counts the blogs sharing an uploaded thumbnail.
+	CountByThumbnail(ctx context.Context, thumbnail string) (int64, error)
+}
+
+// LikeRepository stores likes and keeps the like counters of blogs.
+type LikeRepository interface {
+	// Toggle unlikes the blog if the user likes it and likes it otherwise,
+	// adjusting the blog's counter in the same transaction. It reports
+	// whether the blog is now liked; ErrDuplicate means a concurrent
+	// request liked it first, and ErrConflict that concurrent requests kept
+	// deadlocking it.
+	Toggle(ctx context.Context, userID, blogID uint) (bool, error)
+	Exists(ctx context.Context, userID, blogID uint) (bool, error)
+}
+
+// CommentRepository stores comments and keeps the comment counters of blogs.
+type CommentRepository interface {
+	// Create inserts a comment and counts it on its blog.
+	Create(ctx context.Context, comment *models.Comment) error
+	FindByID(ctx context.Context, id uint) (models.Comment, error)
+	// Delete removes a comment and uncounts it on its blog.
+	Delete(ctx context.Context, comment models.Comment) error
+	// ListByBlog returns the comments of a blog, newest first, with their User loaded.
+	ListByBlog(ctx context.Context, blogID uint) ([]models.Comment, error)
+}
+
+// Repositories groups the repositories of every aggregate.
+type Repositories struct {
+	Users    UserRepository
+	Blogs    BlogRepository
+	Likes    LikeRepository
+	Comments CommentRepository
+}
@@ -0,0 +1,159 @@
+package search
+
+import "strings"
+
+// token is a word of an indexed text: the lower-cased original and its stem.
+type token struct {
+	raw  string
+	stem string
+}
+
+// analyze splits text into words, drops stopwords and stems what is left.
+func analyze(text string) []token {
+	var tokens []token
+	for _, word := range normalizeWords(text) {
+		if stopwords[word] {
+			continue
+		}
+		tokens = append(tokens, token{raw: word, stem: stem(word)})
+	}
+	return tokens
+}
+
+// minStemLength prevents the stemmer from reducing words to meaningless stubs.
+const minStemLength = 4
+
+// stem reduces an Indonesian or English word to its root with a light,
+// rule-based stemmer. The language of a word is not known, so English
+// inflections are removed first and Indonesian affixes second; every rule
+// only applies when the remaining stem is at least minStemLength long.
+// Queries and documents go through the same function, so occasional
+// over-stemming only affects precision, never whether a word finds itself.
+func stem(word string) string {
+	if len(word) <= minStemLength {
+		return word
+	}
+	word = stemEnglish(word)
+	word = stemIndonesian(word)
+	return word
+}
+
+// stemEnglish strips common English inflectional suffixes.
+func stemEnglish(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word)-3 >= minStemLength-1:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") &&
+		!strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is") && len(word)-1 >= minStemLength:
+		word = word[:len(word)-1]
+	}
+
+	for _, suffix := range []string{"ing", "ed", "ly"} {
+		if strings.HasSuffix(word, suffix) && len(word)-len(suffix) >= minStemLength {
+			word = word[:len(word)-len(suffix)]
+			// "running" -> "runn" -> "run"
+			if n := len(word); n >= minStemLength && word[n-1] == word[n-2] && !isVowel(word[n-1]) && word[n-1] != 'l' && word[n-1] != 's' {
+				word = word[:n-1]
+			}
+			break
+		}
+	}
+
+	return word
+}
+
+// Indonesian affixes, longest first within each group.
+var (
+	idParticles   = []string{"lah", "kah", "tah", "pun"}
+	idPossessives = []string{"nya", "ku", "mu"}
+	idSuffixes    = []string{"kan", "an"}
+	idPrefixes    = []string{"meng", "meny", "mem", "men", "me", "peng", "peny", "pem", "pen", "per", "pe", "ber", "ter", "di", "ke", "se"}
+)
+
+// stemIndonesian strips Indonesian particles, possessive pronouns,
+// derivational suffixes and one derivational prefix, following the order
+// of the Nazief-Adriani algorithm without its dictionary lookups.
+func stemIndonesian(word string) string {
+	word = trimSuffixes(word, idParticles)
+	word = trimSuffixes(word, idPossessives)
+	word = trimSuffixes(word, idSuffixes)
+
+	for _, prefix := range idPrefixes {
+		if !strings.HasPrefix(word, prefix) || len(word)-len(prefix) < minStemLength {
+			continue
+		}
+		if root, ok := removePrefix(prefix, word[len(prefix):]); ok {
+			return root
+		}
+	}
+
+	return word
+}
+
+// removePrefix returns the root left after removing prefix from a word, or
+// false when the prefix does not apply to the following letter. Nasal
+// prefixes replace the first letter of a root starting with a vowel:
+// menyapu -> sapu, memukul -> pukul, menulis -> tulis.
+func removePrefix(prefix, rest string) (string, bool) {
+	first := rest[0]
+
+	switch prefix {
+	case "meng", "peng":
+		return rest, isVowel(first) || strings.IndexByte("ghkq", first) >= 0
+	case "meny", "peny":
+		return "s" + rest, isVowel(first)
+	case "mem", "pem":
+		if isVowel(first) {
+			return "p" + rest, true
+		}
+		return rest, strings.IndexByte("bfpv", first) >= 0
+	case "men", "pen":
+		if isVowel(first) {
+			return "t" + rest, true
+		}
+		return rest, strings.IndexByte("cdjtz", first) >= 0
+	case "me", "pe":
+		return rest, strings.IndexByte("lrwy", first) >= 0
+	default:
+		return rest, true
+	}
+}
+
+// trimSuffixes removes the first matching suffix that leaves a long enough stem.
+func trimSuffixes(word string, suffixes []string) string {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(word, suffix) && len(word)-len(suffix) >= minStemLength {
+			return word[:len(word)-len(suffix)]
+		}
+	}
+	return word
+}
+
+func isVowel(b byte) bool {
+	return strings.IndexByte("aeiou", b) >= 0
+}
+
+// stopwords lists common Indonesian and English words that are not indexed.
+var stopwords = toSet(
+	// Indonesian
+	"ada", "adalah", "agar", "akan", "aku", "anda", "atau", "bagi", "bahwa", "belum",
+	"bisa", "dalam", "dan", "dari", "dengan", "di", "dia", "harus", "hanya", "ini",
+	"itu", "jika", "juga", "kami", "karena", "ke", "kita", "lagi", "lebih", "mereka",
+	"namun", "oleh", "pada", "saat", "saja", "sangat", "saya", "sebagai", "sudah",
+	"tentang", "tersebut", "tidak", "untuk", "yang",
+	// English
+	"a", "about", "an", "and", "are", "as", "at", "be", "but", "by", "for", "from",
+	"has", "have", "how", "i", "if", "in", "into", "is", "it", "its", "not", "of",
+	"on", "or", "that", "the", "their", "this", "to", "was", "were", "what", "when",
+	"which", "who", "will", "with", "you",
+)
+
+func toSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
@@ -0,0 +1,159 @@
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Highlight markers wrapped around matched words. The surrounding text is
+// HTML-escaped, so the snippet can be rendered as HTML safely.
+const (
+	MarkOpen  = "<mark>"
+	MarkClose = "</mark>"
+)
+
+// span is a matched range of runes [start, end).
+type span struct{ start, end int }
+
+// Highlight returns text with every match of q wrapped in MarkOpen/MarkClose.
+// When maxRunes is positive and text is longer, a window of about maxRunes
+// around the first match is returned, with "…" marking cut-off ends.
+func Highlight(text string, q Query, maxRunes int) string {
+	runes := []rune(text)
+	spans := findMatches(runes, q)
+
+	// Choose the window to show
+	start, end := 0, len(runes)
+	if maxRunes > 0 && len(runes) > maxRunes {
+		if len(spans) > 0 {
+			start = spans[0].start - maxRunes/4
+		}
+		if start < 0 {
+			start = 0
+		}
+		end = start + maxRunes
+		if end > len(runes) {
+			end = len(runes)
+			start = end - maxRunes
+		}
+		start, end = wordBoundary(runes, start, -1), wordBoundary(runes, end, 1)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+
+	pos := start
+	for _, s := range spans {
+		if s.end <= start || s.start >= end {
+			continue
+		}
+		from, to := max(s.start, start), min(s.end, end)
+		b.WriteString(html.EscapeString(string(runes[pos:from])))
+		b.WriteString(MarkOpen)
+		b.WriteString(html.EscapeString(string(runes[from:to])))
+		b.WriteString(MarkClose)
+		pos = to
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:end])))
+
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
+
+// HasMatch reports whether any term of q matches text.
+func HasMatch(text string, q Query) bool {
+	return len(findMatches([]rune(text), q)) > 0
+}
+
+// findMatches returns the sorted, non-overlapping spans of text matched by q.
+// Words match on whole-word boundaries (or by stem for stemmed queries),
+// prefix terms match the start of a word and phrases match consecutive words.
+func findMatches(text []rune, q Query) []span {
+	lower := []rune(strings.ToLower(string(text)))
+	if len(lower) != len(text) {
+		// Lower-casing changed the length; fall back to a rune-by-rune mapping
+		lower = make([]rune, len(text))
+		for i, r := range text {
+			lower[i] = unicode.ToLower(r)
+		}
+	}
+
+	// Index the start and end of every word
+	var words []span
+	for i := 0; i < len(lower); {
+		if !isWordRune(lower[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(lower) && isWordRune(lower[j]) {
+			j++
+		}
+		words = append(words, span{i, j})
+		i = j
+	}
+
+	var spans []span
+	for _, term := range q.Terms {
+		termWords := term.Words()
+		for i := 0; i+len(termWords) <= len(words); i++ {
+			matched := true
+			for k, tw := range termWords {
+				w := string(lower[words[i+k].start:words[i+k].end])
+				last := k == len(termWords)-1
+				if !wordMatches(w, tw, last && term.Prefix, q.stemmed) {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				spans = append(spans, span{words[i].start, words[i+len(termWords)-1].end})
+			}
+		}
+	}
+
+	// Sort and merge overlapping spans
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	var merged []span
+	for _, s := range spans {
+		if n := len(merged); n > 0 && s.start <= merged[n-1].end {
+			merged[n-1].end = max(merged[n-1].end, s.end)
+			continue
+		}
+		merged = append(merged, s)
+	}
+
+	return merged
+}
+
+// wordMatches reports whether a word of the text matches a word of a term.
+func wordMatches(word, termWord string, prefix, stemmed bool) bool {
+	switch {
+	case word == termWord:
+		return true
+	case prefix:
+		return strings.HasPrefix(word, termWord)
+	case stemmed:
+		return stem(word) == stem(termWord)
+	}
+	return false
+}
+
+// wordBoundary moves pos in direction dir until it is not inside a word.
+func wordBoundary(text []rune, pos, dir int) int {
+	for pos > 0 && pos < len(text) && isWordRune(text[pos]) && isWordRune(text[pos-1]) {
+		pos += dir
+	}
+	return pos
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,329 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/pagination"
+	"gorm.io/gorm"
+)
+
+// BM25 parameters: term frequency saturation and length normalisation.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// document is an indexed blog with the analysed tokens of each field.
+type document struct {
+	blog   models.Blog
+	fields map[string][]token // Keyed by field name: "judul", "content", "username"
+}
+
+// Memory is an in-process inverted index over blogs, scored with BM25 and
+// tokenised with the Indonesian/English analyzer. It is built from the
+// database at startup and must be kept current through Index and Remove;
+// every application instance holds its own copy. The like and comment
+// counters change too often to index: once loaded from a database, the
+// hits take them from it.
+type Memory struct {
+	db           *gorm.DB // Source of the counters; nil keeps the indexed ones
+	mu           sync.RWMutex
+	docs         map[uint]*document
+	postings     map[string]map[uint]struct{} // Stem -> ids of the blogs containing it
+	vocabulary   map[string]string            // Lower-cased word -> stem, for prefix queries
+	fieldLengths map[string]int               // Total number of tokens per field
+}
+
+// NewMemory creates an empty in-memory index.
+func NewMemory() *Memory {
+	return &Memory{
+		docs:         make(map[uint]*document),
+		postings:     make(map[string]map[uint]struct{}),
+		vocabulary:   make(map[string]string),
+		fieldLengths: make(map[string]int),
+	}
+}
+
+// Load indexes every blog stored in db, and reads the counters of the hits
+// from it from then on.
+func (m *Memory) Load(db *gorm.DB) error {
+	m.db = db
+	var blogs []models.Blog
+	return db.Preload("User").FindInBatches(&blogs, 500, func(tx *gorm.DB, batch int) error {
+		for _, blog := range blogs {
+			m.Index(blog)
+		}
+		return nil
+	}).Error
+}
+
+// Index adds or replaces a blog.
+func (m *Memory) Index(blog models.Blog) {
+	doc := &document{
+		blog: blog,
+		fields: map[string][]token{
+			"judul":    analyze(blog.Judul),
+			"content":  analyze(blog.Content),
+			"username": analyze(blog.User.Name),
+		},
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(blog.ID)
+	m.docs[blog.ID] = doc
+	for name, tokens := range doc.fields {
+		m.fieldLengths[name] += len(tokens)
+		for _, tok := range tokens {
+			if m.postings[tok.stem] == nil {
+				m.postings[tok.stem] = make(map[uint]struct{})
+			}
+			m.postings[tok.stem][blog.ID] = struct{}{}
+			m.vocabulary[tok.raw] = tok.stem
+		}
+	}
+}
+
+// Remove deletes a blog from the index.
+func (m *Memory) Remove(id uint) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(id)
+}
+
+// remove deletes a blog; the caller must hold the write lock.
+func (m *Memory) remove(id uint) {
+	doc, ok := m.docs[id]
+	if !ok {
+		return
+	}
+
+	delete(m.docs, id)
+	for name, tokens := range doc.fields {
+		m.fieldLengths[name] -= len(tokens)
+		for _, tok := range tokens {
+			if ids := m.postings[tok.stem]; ids != nil {
+				delete(ids, id)
+				if len(ids) == 0 {
+					delete(m.postings, tok.stem)
+				}
+			}
+		}
+	}
+}
+
+// position is one word of a compiled query term.
+type position struct {
+	stem   string // Matches tokens with this stem
+	prefix string // When set, matches tokens whose word or stem starts with it
+}
+
+func (p position) matches(tok token) bool {
+	if p.prefix != "" {
+		return strings.HasPrefix(tok.raw, p.prefix) || strings.HasPrefix(tok.stem, p.prefix)
+	}
+	return tok.stem == p.stem
+}
+
+// matcher is a compiled query term: one position for words, several consecutive ones for phrases.
+type matcher []position
+
+// count returns how often the term occurs in tokens.
+func (mt matcher) count(tokens []token) int {
+	n := 0
+	for i := 0; i+len(mt) <= len(tokens); i++ {
+		matched := true
+		for k, p := range mt {
+			if !p.matches(tokens[i+k]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			n++
+		}
+	}
+	return n
+}
+
+// compile analyses the terms of q into matchers. Terms made only of stopwords are dropped.
+func (m *Memory) compile(q Query) []matcher {
+	var matchers []matcher
+	for _, term := range q.Terms {
+		tokens := analyze(term.Text)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		mt := make(matcher, len(tokens))
+		for i, tok := range tokens {
+			mt[i] = position{stem: tok.stem}
+		}
+		if term.Prefix {
+			// Match the prefix as typed, before stemming
+			words := term.Words()
+			mt[len(mt)-1] = position{prefix: words[len(words)-1]}
+		}
+		matchers = append(matchers, mt)
+	}
+	return matchers
+}
+
+// candidates returns the ids of the blogs that may contain every position of mt.
+func (m *Memory) candidates(mt matcher) map[uint]struct{} {
+	var result map[uint]struct{}
+	for _, p := range mt {
+		ids := make(map[uint]struct{})
+		if p.prefix != "" {
+			for word, stem := range m.vocabulary {
+				if strings.HasPrefix(word, p.prefix) || strings.HasPrefix(stem, p.prefix) {
+					for id := range m.postings[stem] {
+						ids[id] = struct{}{}
+					}
+				}
+			}
+		} else {
+			for id := range m.postings[p.stem] {
+				ids[id] = struct{}{}
+			}
+		}
+
+		// Intersect with the previous positions
+		if result != nil {
+			for id := range result {
+				if _, ok := ids[id]; !ok {
+					delete(result, id)
+				}
+			}
+		} else {
+			result = ids
+		}
+	}
+	return result
+}
+
+// Search returns a page of hits ordered by BM25 score, newest first on ties.
+// An empty query returns all blogs, newest first.
+func (m *Memory) Search(ctx context.Context, req Request) (pagination.PaginateResult, error) {
+	q := ParseQuery(req.Query)
+	q.stemmed = true
+	fields := fieldsFor(req.Filter)
+
+	m.mu.RLock()
+	var hits []Hit
+	if q.Empty() {
+		hits = make([]Hit, 0, len(m.docs))
+		for _, doc := range m.docs {
+			hits = append(hits, Hit{Blog: doc.blog})
+		}
+	} else {
+		hits = m.score(m.compile(q), fields)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].CreatedAt.After(hits[j].CreatedAt)
+	})
+
+	// Cut out the requested page
+	total := len(hits)
+	start := min((req.Page-1)*req.PerPage, total)
+	end := min(start+req.PerPage, total)
+	page := hits[start:end]
+	for i := range page {
+		page[i].Highlights = highlights(page[i].Blog, q, fields)
+	}
+	if err := m.loadCounters(ctx, page); err != nil {
+		return pagination.PaginateResult{}, err
+	}
+
+	return pagination.NewResult(page, req.Page, req.PerPage, int64(total)), nil
+}
+
+// loadCounters replaces the indexed like and comment counters of hits with
+// the current ones.
+func (m *Memory) loadCounters(ctx context.Context, hits []Hit) error {
+	if m.db == nil || len(hits) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	var counters []models.Blog
+	if err := m.db.WithContext(ctx).Select("id", "like_count", "comment_count").Where("id IN ?", ids).Find(&counters).Error; err != nil {
+		return err
+	}
+
+	current := make(map[uint]models.Blog, len(counters))
+	for _, blog := range counters {
+		current[blog.ID] = blog
+	}
+	for i := range hits {
+		if blog, ok := current[hits[i].ID]; ok {
+			hits[i].LikeCount, hits[i].CommentCount = blog.LikeCount, blog.CommentCount
+		}
+	}
+	return nil
+}
+
+// score computes the BM25 score of every blog matching at least one matcher,
+// summed over the searched fields weighted like the SQL backend. The caller
+// must hold the read lock.
+func (m *Memory) score(matchers []matcher, fields []field) []Hit {
+	total := float64(len(m.docs))
+	scores := make(map[uint]float64)
+
+	for _, mt := range matchers {
+		// Count occurrences per blog and field, and the document frequency
+		counts := make(map[uint]map[string]int)
+		for id := range m.candidates(mt) {
+			doc := m.docs[id]
+			perField := make(map[string]int)
+			for name, tokens := range doc.fields {
+				if n := mt.count(tokens); n > 0 {
+					perField[name] = n
+				}
+			}
+			if len(perField) > 0 {
+				counts[id] = perField
+			}
+		}
+		if len(counts) == 0 {
+			continue
+		}
+
+		df := float64(len(counts))
+		idf := math.Log(1 + (total-df+0.5)/(df+0.5))
+
+		for id, perField := range counts {
+			for _, f := range fields {
+				tf := float64(perField[f.name])
+				if tf == 0 {
+					continue
+				}
+				length := float64(len(m.docs[id].fields[f.name]))
+				average := float64(m.fieldLengths[f.name]) / total
+				norm := 1 - bm25B + bm25B*length/average
+				scores[id] += f.weight * idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{Blog: m.docs[id].blog, Score: score})
+	}
+	return hits
+}
@@ -0,0 +1,65 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Term is a single element of a parsed search query.
+type Term struct {
+	Text   string // Lower-cased word, or words separated by single spaces for phrases
+	Phrase bool   // Quoted phrase: the words must appear next to each other
+	Prefix bool   // Prefix query ("word*"): matches any word starting with Text
+}
+
+// Words returns the individual words of the term.
+func (t Term) Words() []string {
+	return strings.Fields(t.Text)
+}
+
+// Query is a parsed search string.
+type Query struct {
+	Terms []Term
+
+	// stemmed makes highlighting match words by stem, as the memory backend does
+	stemmed bool
+}
+
+// Empty reports whether the query has no searchable terms.
+func (q Query) Empty() bool {
+	return len(q.Terms) == 0
+}
+
+// ParseQuery splits a user search string into terms. Text between double
+// quotes becomes a phrase and a trailing '*' turns a word into a prefix
+// query. Punctuation is dropped so the result is safe to embed in the
+// FULLTEXT and tsquery syntaxes.
+func ParseQuery(input string) Query {
+	var query Query
+
+	// Odd-numbered segments were inside double quotes
+	for i, segment := range strings.Split(input, `"`) {
+		if i%2 == 1 {
+			if words := normalizeWords(segment); len(words) > 0 {
+				query.Terms = append(query.Terms, Term{Text: strings.Join(words, " "), Phrase: len(words) > 1})
+			}
+			continue
+		}
+
+		for _, field := range strings.Fields(segment) {
+			prefix := strings.HasSuffix(field, "*")
+			for _, word := range normalizeWords(field) {
+				query.Terms = append(query.Terms, Term{Text: word, Prefix: prefix})
+			}
+		}
+	}
+
+	return query
+}
+
+// normalizeWords lower-cases text and splits it into words made of letters and digits.
+func normalizeWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
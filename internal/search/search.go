@@ -0,0 +1,53 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/config"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/pagination"
+	"gorm.io/gorm"
+)
+
+// Searcher is implemented by every search backend, so the SQL and the
+// embedded backends are interchangeable.
+type Searcher interface {
+	// Search returns a page of hits whose Data is a []Hit.
+	Search(ctx context.Context, req Request) (pagination.PaginateResult, error)
+	// Index adds or replaces a blog. The blog's User must be loaded.
+	Index(blog models.Blog)
+	// Remove deletes a blog from the index.
+	Remove(id uint)
+}
+
+// Default is the search backend used by the application.
+var Default Searcher
+
+// Init creates the search backend selected by cfg.Backend ("sql" or
+// "memory") and assigns it to Default. The memory backend is built from
+// the blogs in db.
+func Init(cfg config.SearchConfig, db *gorm.DB) {
+	var err error
+	Default, err = New(cfg.Backend, db)
+	if err != nil {
+		panic("Search initialization failed: " + err.Error())
+	}
+}
+
+// New creates the named search backend.
+func New(backend string, db *gorm.DB) (Searcher, error) {
+	switch strings.ToLower(backend) {
+	case "", "sql":
+		return NewSQL(db), nil
+	case "memory":
+		memory := NewMemory()
+		if err := memory.Load(db); err != nil {
+			return nil, err
+		}
+		return memory, nil
+	default:
+		return nil, fmt.Errorf("unknown search backend %q", backend)
+	}
+}
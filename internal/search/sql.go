@@ -0,0 +1,224 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/pagination"
+	"gorm.io/gorm"
+)
+
+// TitleBoost multiplies the relevance of matches in the blog title.
+const TitleBoost = 3.0
+
+// SnippetLength is the approximate number of characters in a content snippet.
+const SnippetLength = 160
+
+// Filters lists the valid values of Request.Filter.
+var Filters = map[string]bool{"username": true, "judul": true, "content": true, "all": true}
+
+// Request describes a paginated search.
+type Request struct {
+	Query   string // Raw search string, see ParseQuery
+	Filter  string // "username", "judul", "content" or "all"
+	Page    int
+	PerPage int
+}
+
+// Hit is a blog matched by a search, with its relevance score and
+// highlighted snippets keyed by field ("judul", "content", "username").
+type Hit struct {
+	models.Blog
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// field is a searchable column and its relevance weight.
+type field struct {
+	name   string // Filter name used in highlights
+	column string // Qualified column name
+	weight float64
+}
+
+// fieldsFor returns the columns searched by a filter.
+func fieldsFor(filter string) []field {
+	all := []field{
+		{name: "judul", column: "blogs.judul", weight: TitleBoost},
+		{name: "content", column: "blogs.content", weight: 1},
+		{name: "username", column: "users.name", weight: 1},
+	}
+	if filter == "" || filter == "all" {
+		return all
+	}
+	for _, f := range all {
+		if f.name == filter {
+			return []field{f}
+		}
+	}
+	return all
+}
+
+// SQL searches blogs with the full-text features of the connected database:
+// FULLTEXT indexes in boolean mode on MySQL and tsvector/tsquery on
+// PostgreSQL (the indexes are created by migration 0002). Other databases
+// fall back to LIKE matching with the same weighting so results are still
+// ranked.
+type SQL struct {
+	db *gorm.DB
+}
+
+// NewSQL creates a database-backed searcher.
+func NewSQL(db *gorm.DB) *SQL {
+	return &SQL{db: db}
+}
+
+// Index does nothing: the SQL backend reads the database directly.
+func (s *SQL) Index(blog models.Blog) {}
+
+// Remove does nothing: the SQL backend reads the database directly.
+func (s *SQL) Remove(id uint) {}
+
+// Search returns a page of hits ordered by relevance, newest first on ties.
+// An empty query returns all blogs, newest first.
+func (s *SQL) Search(ctx context.Context, req Request) (pagination.PaginateResult, error) {
+	q := ParseQuery(req.Query)
+	fields := fieldsFor(req.Filter)
+
+	rawFunc := func(db *gorm.DB) *gorm.DB {
+		query := db.Preload("User")
+		if q.Empty() {
+			return query.Order("blogs.created_at DESC")
+		}
+
+		// Only join users when the author name is searched
+		for _, f := range fields {
+			if f.name == "username" {
+				query = query.Joins("LEFT JOIN users ON users.id = blogs.user_id")
+				break
+			}
+		}
+
+		relevance, relevanceVars, where, whereVars := s.clauses(q, fields)
+		return query.Select("blogs.*, "+relevance+" AS relevance", relevanceVars...).
+			Where(where, whereVars...).
+			Order("relevance DESC").
+			Order("blogs.created_at DESC")
+	}
+
+	var blogs []models.Blog
+	result, err := pagination.Paginate(s.db.WithContext(ctx), req.Page, req.PerPage, rawFunc, &blogs)
+	if err != nil {
+		return result, err
+	}
+
+	hits := make([]Hit, len(blogs))
+	for i, blog := range blogs {
+		hits[i] = Hit{Blog: blog, Score: blog.Relevance, Highlights: highlights(blog, q, fields)}
+	}
+	result.Data = hits
+
+	return result, nil
+}
+
+// clauses builds the relevance expression and the WHERE condition for the
+// connected database, along with their bind variables.
+func (s *SQL) clauses(q Query, fields []field) (string, []interface{}, string, []interface{}) {
+	var scores, conditions []string
+	var scoreVars, conditionVars []interface{}
+
+	switch s.db.Dialector.Name() {
+	case "mysql":
+		against := booleanQuery(q)
+		for _, f := range fields {
+			match := fmt.Sprintf("MATCH(%s) AGAINST(? IN BOOLEAN MODE)", f.column)
+			scores = append(scores, fmt.Sprintf("%s * %g", match, f.weight))
+			scoreVars = append(scoreVars, against)
+			conditions = append(conditions, match)
+			conditionVars = append(conditionVars, against)
+		}
+	case "postgres":
+		tsQuery := textSearchQuery(q)
+		for _, f := range fields {
+			vector := fmt.Sprintf("to_tsvector('simple', coalesce(%s, ''))", f.column)
+			scores = append(scores, fmt.Sprintf("ts_rank(%s, to_tsquery('simple', ?)) * %g", vector, f.weight))
+			scoreVars = append(scoreVars, tsQuery)
+			conditions = append(conditions, vector+" @@ to_tsquery('simple', ?)")
+			conditionVars = append(conditionVars, tsQuery)
+		}
+	default:
+		for _, f := range fields {
+			for _, term := range q.Terms {
+				pattern := "%" + term.Text + "%"
+				scores = append(scores, fmt.Sprintf("(CASE WHEN LOWER(%s) LIKE ? THEN %g ELSE 0 END)", f.column, f.weight))
+				scoreVars = append(scoreVars, pattern)
+				conditions = append(conditions, fmt.Sprintf("LOWER(%s) LIKE ?", f.column))
+				conditionVars = append(conditionVars, pattern)
+			}
+		}
+	}
+
+	return "(" + strings.Join(scores, " + ") + ")", scoreVars,
+		"(" + strings.Join(conditions, " OR ") + ")", conditionVars
+}
+
+// booleanQuery converts q into a MySQL boolean-mode expression. Terms are
+// optional so blogs matching more of them rank higher.
+func booleanQuery(q Query) string {
+	parts := make([]string, 0, len(q.Terms))
+	for _, term := range q.Terms {
+		switch {
+		case term.Phrase:
+			parts = append(parts, `"`+term.Text+`"`)
+		case term.Prefix:
+			parts = append(parts, term.Text+"*")
+		default:
+			parts = append(parts, term.Text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// textSearchQuery converts q into a PostgreSQL tsquery. Terms are OR-ed,
+// phrases use the followed-by operator and prefixes use ":*".
+func textSearchQuery(q Query) string {
+	parts := make([]string, 0, len(q.Terms))
+	for _, term := range q.Terms {
+		words := term.Words()
+		for i, word := range words {
+			words[i] = "'" + word + "'"
+		}
+		if term.Prefix {
+			words[len(words)-1] += ":*"
+		}
+		if len(words) > 1 {
+			parts = append(parts, "("+strings.Join(words, " <-> ")+")")
+		} else {
+			parts = append(parts, words[0])
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+// highlights builds the highlighted snippets of a blog for the searched fields.
+func highlights(blog models.Blog, q Query, fields []field) map[string]string {
+	if q.Empty() {
+		return nil
+	}
+
+	result := make(map[string]string, len(fields))
+	for _, f := range fields {
+		switch f.name {
+		case "judul":
+			result["judul"] = Highlight(blog.Judul, q, 0)
+		case "content":
+			result["content"] = Highlight(blog.Content, q, SnippetLength)
+		case "username":
+			if HasMatch(blog.User.Name, q) {
+				result["username"] = Highlight(blog.User.Name, q, 0)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/imaging"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/logging"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/metrics"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/pagination"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/repository"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/search"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/storage"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/suggest"
+)
+
+// BlogService publishes, lists and deletes blogs and their thumbnails.
+type BlogService struct {
+	blogs    repository.BlogRepository
+	likes    repository.LikeRepository
+	comments repository.CommentRepository
+	store    storage.Storage
+	indexes  indexes
+	// thumbnails serialises the writing, publishing and releasing of each
+	// thumbnail within the process, so that a blog is never left pointing at
+	// deleted files
+	thumbnails keyedMutex
+}
+
+// NewBlogService creates a BlogService.
+func NewBlogService(blogs repository.BlogRepository, likes repository.LikeRepository, comments repository.CommentRepository, opts Options) *BlogService {
+	return &BlogService{
+		blogs:    blogs,
+		likes:    likes,
+		comments: comments,
+		store:    opts.Storage,
+		indexes:  indexes{searcher: opts.Searcher, suggestions: opts.Suggestions},
+	}
+}
+
+// BlogDetail is a blog with its comments and whether the viewer likes it.
+type BlogDetail struct {
+	Blog     models.Blog
+	Liked    bool
+	Comments []models.Comment
+}
+
+// findBlog loads a blog, reporting ErrBlogNotFound when it does not exist.
+// Every service that acts on a blog checks it exists through here.
+func findBlog(ctx context.Context, blogs repository.BlogRepository, id uint) (models.Blog, error) {
+	blog, err := blogs.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return blog, ErrBlogNotFound
+	}
+	return blog, err
+}
+
+// List returns a page of blogs whose Data is a *[]models.Blog.
+func (s *BlogService) List(ctx context.Context, opts repository.ListOptions) (pagination.PaginateResult, error) {
+	return s.blogs.List(ctx, opts)
+}
+
+// Search runs a full-text search ranked by relevance.
+func (s *BlogService) Search(ctx context.Context, req search.Request) (pagination.PaginateResult, error) {
+	return s.indexes.searcher.Search(ctx, req)
+}
+
+// Suggest returns completions for a search-box prefix.
+func (s *BlogService) Suggest(query string, limit int) suggest.Result {
+	if s.indexes.suggestions == nil {
+		return suggest.Result{Titles: []suggest.Suggestion{}, Authors: []suggest.Suggestion{}}
+	}
+	return s.indexes.suggestions.Suggest(query, limit)
+}
+
+// Get returns a blog with its comments and whether viewerID likes it.
+func (s *BlogService) Get(ctx context.Context, id, viewerID uint) (BlogDetail, error) {
+	blog, err := findBlog(ctx, s.blogs, id)
+	if err != nil {
+		return BlogDetail{}, err
+	}
+
+	liked, err := s.likes.Exists(ctx, viewerID, id)
+	if err != nil {
+		return BlogDetail{}, err
+	}
+
+	comments, err := s.comments.ListByBlog(ctx, id)
+	if err != nil {
+		return BlogDetail{}, err
+	}
+
+	return BlogDetail{Blog: blog, Liked: liked, Comments: comments}, nil
+}
+
+// Create processes the uploaded thumbnail, stores it and publishes the blog.
+// Errors from imaging are wrapped in ErrProcessThumbnail.
+func (s *BlogService) Create(ctx context.Context, userID uint, judul, content string, thumbnail []byte) (models.Blog, error) {
+	// Validate and decode the image, strip its metadata and generate the resized variants
+	processed, err := imaging.Process(thumbnail)
+	if err != nil {
+		return models.Blog{}, fmt.Errorf("%w: %w", ErrProcessThumbnail, err)
+	}
+
+	// Name the file after its content hash so identical uploads are stored once;
+	// the extension comes from the detected type, never from the client
+	hash := sha256.Sum256(processed.Original)
+	fileName := hex.EncodeToString(hash[:]) + processed.Extension()
+
+	// Until the blog is saved, a Delete of the last blog using an identical
+	// upload must not remove the files, nor a failure remove the files of an
+	// identical upload being published
+	unlock := s.thumbnails.Lock(fileName)
+	defer unlock()
+
+	// Save the processed image and its variants even when an identical upload
+	// exists: the names come from the content, so writing them again is
+	// harmless, and it restores files the last Delete may have removed
+	files := map[string][]byte{fileName: processed.Original}
+	for width, variant := range processed.Variants {
+		files[imaging.VariantName(fileName, width)] = variant
+	}
+	for name, content := range files {
+		if err := s.store.Put(ctx, name, bytes.NewReader(content), processed.ContentType()); err != nil {
+			// Remove whatever was already written, unless another blog uses it
+			s.releaseThumbnail(ctx, fileName)
+			return models.Blog{}, fmt.Errorf("%w: %w", ErrStoreThumbnail, err)
+		}
+	}
+
+	blog := models.Blog{
+		Judul:     judul,
+		Content:   content,
+		Thumbnail: fileName, // Save only the file name in the database
+		UserID:    userID,
+	}
+	if err := s.blogs.Create(ctx, &blog); err != nil {
+		s.releaseThumbnail(ctx, fileName)
+		return models.Blog{}, err
+	}
+
+	s.indexes.add(blog)
+	metrics.BlogsPosted.Inc()
+	return blog, nil
+}
+
+// Delete removes a blog owned by userID, and its thumbnail unless another
+// blog still uses the same upload.
+func (s *BlogService) Delete(ctx context.Context, userID, id uint) (models.Blog, error) {
+	blog, err := findBlog(ctx, s.blogs, id)
+	if err != nil {
+		return blog, err
+	}
+
+	if blog.UserID != userID {
+		return blog, ErrForbidden
+	}
+
+	if err := s.blogs.Delete(ctx, blog); err != nil {
+		return blog, err
+	}
+
+	s.indexes.remove(blog.ID)
+
+	unlock := s.thumbnails.Lock(blog.Thumbnail)
+	s.releaseThumbnail(ctx, blog.Thumbnail)
+	unlock()
+
+	return blog, nil
+}
+
+// ThumbnailURL returns the publicly accessible URL of an uploaded file.
+func (s *BlogService) ThumbnailURL(fileName string) string {
+	return s.store.URL(fileName)
+}
+
+// releaseThumbnail deletes a thumbnail once no blog uses it anymore. The
+// caller holds the lock of the thumbnail.
+func (s *BlogService) releaseThumbnail(ctx context.Context, fileName string) {
+	if references, err := s.blogs.CountByThumbnail(ctx, fileName); err == nil && references == 0 {
+		s.deleteThumbnail(ctx, fileName)
+	}
+}
+
+// deleteThumbnail removes a thumbnail and all of its resized variants from storage.
+func (s *BlogService) deleteThumbnail(ctx context.Context, fileName string) {
+	if fileName == "" {
+		return
+	}
+
+	names := []string{fileName}
+	for _, width := range imaging.Widths {
+		names = append(names, imaging.VariantName(fileName, width))
+	}
+
+	for _, name := range names {
+		if err := s.store.Delete(ctx, name); err != nil {
+			logging.FromContext(ctx).Warn("deleting a thumbnail failed", "file", name, "error", err)
+		}
+	}
+}
+
+// keyedMutex is a mutex per key, such as a thumbnail's file name.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	users int // Holders and waiters; the lock is dropped at zero
+}
+
+// Lock locks key and returns the function unlocking it.
+func (k *keyedMutex) Lock(key string) (unlock func()) {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*keyedLock)
+	}
+	lock, ok := k.locks[key]
+	if !ok {
+		lock = &keyedLock{}
+		k.locks[key] = lock
+	}
+	lock.users++
+	k.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		k.mu.Lock()
+		if lock.users--; lock.users == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
+
+// indexes keeps the search and suggestion indexes in step with the
+// database; either may be nil.
+type indexes struct {
+	searcher    search.Searcher
+	suggestions *suggest.Index
+}
+
+// add indexes a new blog; its User must be loaded.
+func (i indexes) add(blog models.Blog) {
+	if i.searcher != nil {
+		i.searcher.Index(blog)
+	}
+	if i.suggestions != nil {
+		i.suggestions.Add(blog, blog.LikeCount)
+	}
+}
+
+func (i indexes) remove(blogID uint) {
+	if i.searcher != nil {
+		i.searcher.Remove(blogID)
+	}
+	if i.suggestions != nil {
+		i.suggestions.Remove(blogID)
+	}
+}
+
+func (i indexes) addLikes(blogID uint, delta int64) {
+	if i.suggestions != nil {
+		i.suggestions.AddLikes(blogID, delta)
+	}
+}
+
+// renameAuthor re-indexes an author's blogs under their new name.
+func (i indexes) renameAuthor(user models.User, blogs []models.Blog) {
+	if i.suggestions != nil {
+		i.suggestions.RenameAuthor(user.ID, user.Name)
+	}
+	if i.searcher != nil {
+		for _, blog := range blogs {
+			blog.User = user
+			i.searcher.Index(blog)
+		}
+	}
+}
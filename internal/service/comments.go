@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/metrics"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/repository"
+)
+
+// CommentService posts, lists and deletes comments.
+type CommentService struct {
+	blogs    repository.BlogRepository
+	comments repository.CommentRepository
+}
+
+// NewCommentService creates a CommentService.
+func NewCommentService(blogs repository.BlogRepository, comments repository.CommentRepository) *CommentService {
+	return &CommentService{blogs: blogs, comments: comments}
+}
+
+// Post comments on a blog as userID.
+func (s *CommentService) Post(ctx context.Context, userID, blogID uint, text string) (models.Comment, error) {
+	if _, err := findBlog(ctx, s.blogs, blogID); err != nil {
+		return models.Comment{}, err
+	}
+
+	comment := models.Comment{
+		Comment: text,
+		UserID:  userID,
+		BlogID:  blogID,
+	}
+	if err := s.comments.Create(ctx, &comment); err != nil {
+		return models.Comment{}, err
+	}
+	metrics.CommentsPosted.Inc()
+	return comment, nil
+}
+
+// Delete removes a comment; only its author may delete it.
+func (s *CommentService) Delete(ctx context.Context, userID, id uint) (models.Comment, error) {
+	comment, err := s.comments.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return comment, ErrCommentNotFound
+	}
+	if err != nil {
+		return comment, err
+	}
+
+	if comment.UserID != userID {
+		return comment, ErrForbidden
+	}
+
+	return comment, s.comments.Delete(ctx, comment)
+}
+
+// List returns the comments of a blog, newest first, with their User loaded.
+func (s *CommentService) List(ctx context.Context, blogID uint) ([]models.Comment, error) {
+	if _, err := findBlog(ctx, s.blogs, blogID); err != nil {
+		return nil, err
+	}
+	return s.comments.ListByBlog(ctx, blogID)
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/metrics"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/repository"
+)
+
+// LikeService likes and unlikes blogs.
+type LikeService struct {
+	blogs   repository.BlogRepository
+	likes   repository.LikeRepository
+	indexes indexes
+}
+
+// NewLikeService creates a LikeService.
+func NewLikeService(blogs repository.BlogRepository, likes repository.LikeRepository, opts Options) *LikeService {
+	return &LikeService{
+		blogs:   blogs,
+		likes:   likes,
+		indexes: indexes{searcher: opts.Searcher, suggestions: opts.Suggestions},
+	}
+}
+
+// Toggle likes the blog if userID does not like it yet and unlikes it
+// otherwise. It reports whether the blog is now liked.
+func (s *LikeService) Toggle(ctx context.Context, userID, blogID uint) (bool, error) {
+	if _, err := findBlog(ctx, s.blogs, blogID); err != nil {
+		return false, err
+	}
+
+	liked, err := s.likes.Toggle(ctx, userID, blogID)
+	switch {
+	case errors.Is(err, repository.ErrDuplicate):
+		// A concurrent request liked the blog first; the outcome is the same
+		return true, nil
+	case errors.Is(err, repository.ErrNotFound):
+		return false, ErrBlogNotFound // Deleted in the meantime
+	case err != nil:
+		return false, err
+	}
+
+	if liked {
+		s.indexes.addLikes(blogID, 1)
+		metrics.LikesToggled.WithLabelValues("liked").Inc()
+	} else {
+		s.indexes.addLikes(blogID, -1)
+		metrics.LikesToggled.WithLabelValues("unliked").Inc()
+	}
+	return liked, nil
+}
+
+// Status returns a blog, with its like counter, and whether userID likes it.
+func (s *LikeService) Status(ctx context.Context, userID, blogID uint) (models.Blog, bool, error) {
+	blog, err := findBlog(ctx, s.blogs, blogID)
+	if err != nil {
+		return blog, false, err
+	}
+
+	liked, err := s.likes.Exists(ctx, userID, blogID)
+	return blog, liked, err
+}
@@ -0,0 +1,54 @@
+// Package service holds the business logic behind the HTTP handlers. The
+// services work on the repositories and indexes they are constructed with,
+// so they can be tested with the in-memory repositories.
+package service
+
+import (
+	"errors"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/mail"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/repository"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/search"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/storage"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/suggest"
+)
+
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrBlogNotFound       = errors.New("blog not found")
+	ErrCommentNotFound    = errors.New("comment not found")
+	ErrForbidden          = errors.New("only the author may do this")
+	ErrEmailTaken         = errors.New("email already exists")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrProcessThumbnail   = errors.New("processing the thumbnail failed")
+	ErrStoreThumbnail     = errors.New("storing the thumbnail failed")
+)
+
+// Options are the dependencies of the services besides the repositories.
+type Options struct {
+	Storage     storage.Storage
+	Searcher    search.Searcher // Nil leaves search results stale
+	Suggestions *suggest.Index  // Nil leaves suggestions stale
+	Secret      string          // Signs the login tokens
+	Lockout     Lockout         // Zero never locks accounts
+	Mailer      mail.Sender     // Nil sends no lockout notices
+}
+
+// Services groups the service of every aggregate.
+type Services struct {
+	Users    *UserService
+	Blogs    *BlogService
+	Likes    *LikeService
+	Comments *CommentService
+}
+
+// New creates the services on top of repos.
+func New(repos repository.Repositories, opts Options) Services {
+	return Services{
+		Users:    NewUserService(repos.Users, repos.Blogs, opts),
+		Blogs:    NewBlogService(repos.Blogs, repos.Likes, repos.Comments, opts),
+		Likes:    NewLikeService(repos.Blogs, repos.Likes, opts),
+		Comments: NewCommentService(repos.Blogs, repos.Comments),
+	}
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/logging"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/mail"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/metrics"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TokenLifetime is how long a login token stays valid.
+const TokenLifetime = 30 * 24 * time.Hour
+
+// mailTimeout bounds the sending of an email in the background.
+const mailTimeout = 30 * time.Second
+
+// UserService signs users up and in and edits their profile.
+type UserService struct {
+	users   repository.UserRepository
+	blogs   repository.BlogRepository
+	indexes indexes
+	secret  string
+	lockout Lockout
+	mailer  mail.Sender
+}
+
+// Lockout locks an account for Duration after Threshold failed logins in a
+// row. Each further lockout before a successful login lasts twice as long,
+// up to MaxDuration.
+type Lockout struct {
+	Threshold   int // 0 never locks
+	Duration    time.Duration
+	MaxDuration time.Duration
+}
+
+// duration returns how long the lockout following previous ones lasts.
+func (l Lockout) duration(previous int) time.Duration {
+	d := l.Duration
+	for range previous {
+		if d >= l.MaxDuration/2 {
+			return l.MaxDuration
+		}
+		d *= 2
+	}
+	return min(d, l.MaxDuration)
+}
+
+// dummyHash is checked against the password of unknown emails, so that
+// they take as long to refuse as wrong passwords and do not reveal which
+// emails have an account. Its cost matches the hashes of Signup.
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, err := bcrypt.GenerateFromPassword([]byte("not the password of anyone"), bcrypt.DefaultCost)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
+// NewUserService creates a UserService.
+func NewUserService(users repository.UserRepository, blogs repository.BlogRepository, opts Options) *UserService {
+	return &UserService{
+		users:   users,
+		blogs:   blogs,
+		indexes: indexes{searcher: opts.Searcher, suggestions: opts.Suggestions},
+		secret:  opts.Secret,
+		lockout: opts.Lockout,
+		mailer:  opts.Mailer,
+	}
+}
+
+// Profile is the editable part of a user.
+type Profile struct {
+	Name         string
+	Email        string
+	TanggalLahir string
+	Biografi     string
+}
+
+// Signup registers a user with a hashed password.
+func (s *UserService) Signup(ctx context.Context, profile Profile, password string) (models.User, error) {
+	if err := s.emailAvailable(ctx, profile.Email); err != nil {
+		return models.User{}, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user := models.User{
+		Name:         profile.Name,
+		Email:        profile.Email,
+		Password:     string(hashedPassword),
+		TanggalLahir: profile.TanggalLahir,
+		Biografi:     profile.Biografi,
+	}
+	if err := s.users.Create(ctx, &user); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return models.User{}, ErrEmailTaken // Lost a race with another signup
+		}
+		return models.User{}, err
+	}
+	metrics.Signups.Inc()
+	return user, nil
+}
+
+// Login checks the credentials of a client at ip and returns a signed JWT
+// whose subject is the user ID. Failed logins are counted per account and
+// lock it once the threshold is reached. Unknown emails, wrong passwords and
+// locked accounts, even with the right password, all get
+// ErrInvalidCredentials after the same hashing and queries, so neither the
+// answer nor its time reveals which emails are registered or locked.
+func (s *UserService) Login(ctx context.Context, email, password, ip string) (string, error) {
+	now := time.Now()
+	user, err := s.users.FindByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		metrics.Logins.WithLabelValues("failed").Inc()
+		// Record the failure against no account, like a wrong password
+		if err := s.loginFailed(ctx, &models.User{}, now); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return "", err
+		}
+		return "", ErrInvalidCredentials
+	}
+	if err != nil {
+		return "", err
+	}
+
+	wrongPassword := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		metrics.Logins.WithLabelValues("locked").Inc()
+		// Counted like a wrong password, which leaves the lock as it is
+		if err := s.loginFailed(ctx, &user, now); err != nil {
+			return "", err
+		}
+		return "", ErrInvalidCredentials
+	}
+	if wrongPassword {
+		metrics.Logins.WithLabelValues("failed").Inc()
+		if err := s.loginFailed(ctx, &user, now); err != nil {
+			return "", err
+		}
+		return "", ErrInvalidCredentials
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": user.ID,                              // Subject (user ID)
+		"exp": time.Now().Add(TokenLifetime).Unix(), // Expiration
+	})
+	signed, err := token.SignedString([]byte(s.secret))
+	if err != nil {
+		return "", err
+	}
+
+	user.FailedLogins, user.Lockouts, user.LockedUntil = 0, 0, nil
+	user.LastLoginAt, user.LastLoginIP = &now, ip
+	if err := s.users.UpdateLoginState(ctx, &user); err != nil {
+		return "", err
+	}
+	metrics.Logins.WithLabelValues("succeeded").Inc()
+	return signed, nil
+}
+
+// VerifyToken checks the signature and expiry of a token signed by Login
+// and returns the ID of its user, or ErrInvalidToken.
+func (s *UserService) VerifyToken(token string) (uint, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return []byte(s.secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+
+	// JSON numbers are decoded as float64
+	sub, ok := claims["sub"].(float64)
+	if !ok || sub <= 0 {
+		return 0, ErrInvalidToken
+	}
+	return uint(sub), nil
+}
+
+// loginFailed counts a failed login of user and locks the account when it
+// reaches the threshold, telling its owner by email.
+func (s *UserService) loginFailed(ctx context.Context, user *models.User, now time.Time) error {
+	if s.lockout.Threshold <= 0 {
+		return nil
+	}
+
+	// Counted by the repository, so that concurrent failures all count and
+	// whether to lock is decided on the stored count
+	stored, locked, err := s.users.RecordLoginFailure(ctx, user.ID, now, s.lockout.Threshold, s.lockout.duration)
+	if err != nil {
+		return err
+	}
+	user.FailedLogins, user.Lockouts, user.LockedUntil = stored.FailedLogins, stored.Lockouts, stored.LockedUntil
+
+	if locked && s.mailer != nil {
+		s.sendLockoutNotice(ctx, *user)
+	}
+	return nil
+}
+
+// sendLockoutNotice emails the owner of a locked account in the background,
+// so that a slow mail server does not delay the answer to the login. The
+// sending outlives the request, within mailTimeout.
+func (s *UserService) sendLockoutNotice(ctx context.Context, user models.User) {
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "Your account has been locked",
+		Body: fmt.Sprintf("Hello %s,\n\nAfter %d failed login attempts your account is locked until %s.\n"+
+			"If they were not yours, someone may be guessing your password; consider changing it once you can log in again.\n",
+			user.Name, s.lockout.Threshold, user.LockedUntil.UTC().Format("2006-01-02 15:04 MST")),
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailTimeout)
+	go func() {
+		defer cancel()
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			// The lock holds anyway
+			logging.FromContext(ctx).Warn("Sending the lockout notice failed", "user_id", user.ID, "error", err)
+		}
+	}()
+}
+
+// Get returns a user.
+func (s *UserService) Get(ctx context.Context, id uint) (models.User, error) {
+	user, err := s.users.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return user, ErrUserNotFound
+	}
+	return user, err
+}
+
+// Update replaces the profile of a user. A new name is carried over to the
+// search and suggestion entries of the user's blogs.
+func (s *UserService) Update(ctx context.Context, id uint, profile Profile) (models.User, error) {
+	user, err := s.Get(ctx, id)
+	if err != nil {
+		return user, err
+	}
+
+	if user.Email != profile.Email {
+		if err := s.emailAvailable(ctx, profile.Email); err != nil {
+			return user, err
+		}
+	}
+
+	nameChanged := user.Name != profile.Name
+	user.Name = profile.Name
+	user.Email = profile.Email
+	user.TanggalLahir = profile.TanggalLahir
+	user.Biografi = profile.Biografi
+
+	if err := s.users.Update(ctx, &user); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return user, ErrEmailTaken
+		}
+		return user, err
+	}
+
+	if nameChanged {
+		if blogs, err := s.blogs.ListByUser(ctx, user.ID); err == nil {
+			s.indexes.renameAuthor(user, blogs)
+		}
+	}
+
+	return user, nil
+}
+
+// EmailTaken reports whether a user already has the address.
+func (s *UserService) EmailTaken(ctx context.Context, email string) (bool, error) {
+	err := s.emailAvailable(ctx, email)
+	if errors.Is(err, ErrEmailTaken) {
+		return true, nil
+	}
+	return false, err
+}
+
+// emailAvailable returns ErrEmailTaken when a user already has the address.
+func (s *UserService) emailAvailable(ctx context.Context, email string) error {
+	_, err := s.users.FindByEmail(ctx, email)
+	switch {
+	case err == nil:
+		return ErrEmailTaken
+	case errors.Is(err, repository.ErrNotFound):
+		return nil
+	default:
+		return err
+	}
+}
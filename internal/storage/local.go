@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Local stores files in a directory on the local disk.
+type Local struct {
+	dir       string
+	urlPrefix string
+}
+
+// NewLocal creates a local-disk storage rooted at dir, creating it if needed.
+// Files are served publicly under urlPrefix.
+func NewLocal(dir, urlPrefix string) (*Local, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("create upload directory: %w", err)
+	}
+	return &Local{dir: dir, urlPrefix: urlPrefix}, nil
+}
+
+// path resolves name inside the storage directory, rejecting path traversal.
+func (l *Local) path(name string) (string, error) {
+	clean := filepath.Clean("/" + name)
+	if clean == "/" || strings.Contains(name, "\\") {
+		return "", fmt.Errorf("invalid file name %q", name)
+	}
+	return filepath.Join(l.dir, clean), nil
+}
+
+// Put writes the file to disk through a temporary file so readers never see partial content.
+func (l *Local) Put(ctx context.Context, name string, r io.Reader, contentType string) error {
+	path, err := l.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get opens the file from disk.
+func (l *Local) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	path, err := l.path(name)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// Delete removes the file from disk.
+func (l *Local) Delete(ctx context.Context, name string) error {
+	path, err := l.path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Ping checks that files can be written to the storage directory by
+// creating and removing a hidden temporary file, so a read-only mount, a
+// full disk or a lost permission fail it.
+func (l *Local) Ping(ctx context.Context) error {
+	probe, err := os.CreateTemp(l.dir, ".ping-*")
+	if err != nil {
+		return err
+	}
+	_, err = probe.Write([]byte("ok"))
+	return errors.Join(err, probe.Close(), os.Remove(probe.Name()))
+}
+
+// URL returns the public path of the file.
+func (l *Local) URL(name string) string {
+	return joinURL(l.urlPrefix, name)
+}
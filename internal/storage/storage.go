@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/config"
+)
+
+// ErrNotFound is returned by Get when the requested file does not exist.
+var ErrNotFound = errors.New("file not found")
+
+// Storage is a backend for uploaded files such as blog thumbnails.
+type Storage interface {
+	// Put stores the content of r under name, replacing any existing file.
+	Put(ctx context.Context, name string, r io.Reader, contentType string) error
+	// Get opens the file stored under name. The caller must close the reader.
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	// Delete removes the file stored under name. Missing files are not an error.
+	Delete(ctx context.Context, name string) error
+	// URL returns the public URL of the file stored under name.
+	URL(name string) string
+	// Ping checks that the backend is reachable and, where that is cheap,
+	// writable.
+	Ping(ctx context.Context) error
+}
+
+// Default is the storage backend used by the application.
+var Default Storage
+
+// Init creates the storage backend selected by cfg.Driver ("local" or "s3")
+// and assigns it to Default, traced.
+func Init(cfg config.StorageConfig) {
+	backend, err := New(cfg)
+	if err != nil {
+		panic("Storage initialization failed: " + err.Error())
+	}
+	Default = Traced(backend)
+}
+
+// New creates the storage backend described by cfg.
+func New(cfg config.StorageConfig) (Storage, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case "local":
+		return NewLocal(cfg.Dir, cfg.URLPrefix)
+	case "s3":
+		publicURL := cfg.S3PublicURL
+		if publicURL == "" {
+			publicURL = cfg.URLPrefix // Serve through the application
+		}
+		return NewS3(S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PublicURL: publicURL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
+
+// joinURL joins a URL prefix and a file name with exactly one slash.
+func joinURL(prefix, name string) string {
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(name, "/")
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traced records the operations of a backend as spans.
+type traced struct {
+	Storage
+}
+
+// Traced returns s recording Put, Get, Delete and Ping as spans, children of the
+// span in their context.
+func Traced(s Storage) Storage {
+	return traced{s}
+}
+
+func (t traced) Put(ctx context.Context, name string, r io.Reader, contentType string) (err error) {
+	ctx, span := startSpan(ctx, "put", name, attribute.String("storage.content_type", contentType))
+	defer func() { endSpan(span, err) }()
+	return t.Storage.Put(ctx, name, r, contentType)
+}
+
+func (t traced) Get(ctx context.Context, name string) (_ io.ReadCloser, err error) {
+	ctx, span := startSpan(ctx, "get", name)
+	defer func() { endSpan(span, err) }()
+	return t.Storage.Get(ctx, name)
+}
+
+func (t traced) Delete(ctx context.Context, name string) (err error) {
+	ctx, span := startSpan(ctx, "delete", name)
+	defer func() { endSpan(span, err) }()
+	return t.Storage.Delete(ctx, name)
+}
+
+func (t traced) Ping(ctx context.Context) (err error) {
+	ctx, span := startSpan(ctx, "ping", "")
+	defer func() { endSpan(span, err) }()
+	return t.Storage.Ping(ctx)
+}
+
+func startSpan(ctx context.Context, operation, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if name != "" {
+		attrs = append(attrs, attribute.String("storage.file", name))
+	}
+	return tracing.Tracer().Start(ctx, "storage."+operation,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// endSpan ends span, marking it failed by err; a missing file is reported to
+// the caller and is not a failure of the backend.
+func endSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,299 @@
+package suggest
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"gorm.io/gorm"
+)
+
+// MaxLimit is the largest number of completions returned per kind.
+const MaxLimit = 10
+
+// Suggestion is a single completion.
+type Suggestion struct {
+	ID    uint   `json:"id"`    // Blog ID for titles, user ID for authors
+	Text  string `json:"text"`  // Title or author name as stored
+	Likes int64  `json:"likes"` // Likes of the blog, or of all the author's blogs
+}
+
+// Result holds the title and author completions for a query.
+type Result struct {
+	Titles  []Suggestion `json:"titles"`
+	Authors []Suggestion `json:"authors"`
+}
+
+// entry is a blog title or an author name that can be completed.
+type entry struct {
+	Suggestion
+	keys []string // Normalised text starting at each word, e.g. "belajar golang", "golang"
+}
+
+// Index is an in-memory completion index over blog titles and author names,
+// weighted by like counts. It is loaded once from the database and kept
+// current through Add, Remove, AddLikes and RenameAuthor.
+type Index struct {
+	mu      sync.RWMutex
+	titles  map[uint]*entry // Keyed by blog ID
+	authors map[uint]*entry // Keyed by user ID
+	owners  map[uint]uint   // Blog ID -> user ID
+	blogs   map[uint]int    // User ID -> number of indexed blogs
+}
+
+// Default is the completion index used by the application.
+var Default = NewIndex()
+
+// Init loads Default from the database.
+func Init(db *gorm.DB) {
+	if err := Default.Load(db); err != nil {
+		panic("Suggestion index initialization failed: " + err.Error())
+	}
+}
+
+// NewIndex creates an empty completion index.
+func NewIndex() *Index {
+	return &Index{
+		titles:  make(map[uint]*entry),
+		authors: make(map[uint]*entry),
+		owners:  make(map[uint]uint),
+		blogs:   make(map[uint]int),
+	}
+}
+
+// Load replaces the content of the index with the blogs stored in db, weighted by their like counters.
+func (idx *Index) Load(db *gorm.DB) error {
+	fresh := NewIndex()
+	var blogs []models.Blog
+	err := db.Preload("User").FindInBatches(&blogs, 500, func(tx *gorm.DB, batch int) error {
+		for _, blog := range blogs {
+			fresh.Add(blog, blog.LikeCount)
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.titles, idx.authors, idx.owners, idx.blogs = fresh.titles, fresh.authors, fresh.owners, fresh.blogs
+
+	return nil
+}
+
+// Add indexes a blog title and its author. The blog's User must be loaded.
+func (idx *Index) Add(blog models.Blog, likes int64) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(blog.ID)
+
+	idx.titles[blog.ID] = newEntry(blog.ID, blog.Judul, likes)
+	idx.owners[blog.ID] = blog.UserID
+	idx.blogs[blog.UserID]++
+
+	author, ok := idx.authors[blog.UserID]
+	if !ok {
+		author = newEntry(blog.UserID, blog.User.Name, 0)
+		idx.authors[blog.UserID] = author
+	}
+	author.Likes += likes
+}
+
+// Remove deletes a blog title, and its author once they have no blogs left.
+func (idx *Index) Remove(blogID uint) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(blogID)
+}
+
+// remove deletes a blog; the caller must hold the write lock.
+func (idx *Index) remove(blogID uint) {
+	title, ok := idx.titles[blogID]
+	if !ok {
+		return
+	}
+	userID := idx.owners[blogID]
+
+	delete(idx.titles, blogID)
+	delete(idx.owners, blogID)
+
+	if author, ok := idx.authors[userID]; ok {
+		author.Likes -= title.Likes
+	}
+	idx.blogs[userID]--
+	if idx.blogs[userID] <= 0 {
+		delete(idx.blogs, userID)
+		delete(idx.authors, userID)
+	}
+}
+
+// AddLikes adjusts the like count of a blog and its author by delta.
+func (idx *Index) AddLikes(blogID uint, delta int64) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	title, ok := idx.titles[blogID]
+	if !ok {
+		return
+	}
+	title.Likes += delta
+	if author, ok := idx.authors[idx.owners[blogID]]; ok {
+		author.Likes += delta
+	}
+}
+
+// RenameAuthor updates the name under which an author is completed.
+func (idx *Index) RenameAuthor(userID uint, name string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if author, ok := idx.authors[userID]; ok {
+		idx.authors[userID] = newEntry(userID, name, author.Likes)
+	}
+}
+
+// Suggest returns up to limit title and author completions for query.
+// Entries where a word starts with the query rank first; otherwise words
+// within a small edit distance of the query prefix are accepted to tolerate
+// typos. Within the same match quality, more liked entries rank higher.
+func (idx *Index) Suggest(query string, limit int) Result {
+	if limit <= 0 || limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	q := normalize(query)
+	if q == "" {
+		return Result{Titles: []Suggestion{}, Authors: []Suggestion{}}
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return Result{
+		Titles:  rank(idx.titles, q, limit),
+		Authors: rank(idx.authors, q, limit),
+	}
+}
+
+// rank scores every entry against q and returns the best limit suggestions.
+func rank(entries map[uint]*entry, q string, limit int) []Suggestion {
+	type scored struct {
+		Suggestion
+		score float64
+	}
+
+	maxDistance := allowedDistance(q)
+	var matches []scored
+	for _, e := range entries {
+		quality := 0.0
+		for i, key := range e.keys {
+			var current float64
+			switch {
+			case strings.HasPrefix(key, q) && i == 0:
+				current = 3 // The text itself starts with the query
+			case strings.HasPrefix(key, q):
+				current = 2 // A later word starts with the query
+			default:
+				if d := prefixDistance(q, key, maxDistance); d <= maxDistance {
+					current = 1 / float64(1+d) // Typo-tolerant match
+				}
+			}
+			quality = math.Max(quality, current)
+		}
+		if quality > 0 {
+			matches = append(matches, scored{e.Suggestion, quality * (1 + math.Log1p(float64(max(e.Likes, 0))))})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].Text < matches[j].Text
+	})
+
+	result := make([]Suggestion, 0, min(limit, len(matches)))
+	for i := 0; i < len(matches) && i < limit; i++ {
+		result = append(result, matches[i].Suggestion)
+	}
+	return result
+}
+
+// allowedDistance returns how many typos are tolerated for a query of this length.
+func allowedDistance(q string) int {
+	switch n := len([]rune(q)); {
+	case n < 3:
+		return 0
+	case n < 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// prefixDistance returns the smallest Levenshtein distance between q and any
+// prefix of key, or maxDistance+1 once it is certain to exceed maxDistance.
+func prefixDistance(q, key string, maxDistance int) int {
+	a, b := []rune(q), []rune(key)
+	if len(b) > len(a)+maxDistance {
+		b = b[:len(a)+maxDistance]
+	}
+
+	// Classic dynamic programming over two rows
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > maxDistance {
+			return maxDistance + 1
+		}
+		prev, curr = curr, prev
+	}
+
+	// The query may match any prefix of key: take the best column of the last row
+	best := prev[0]
+	for _, d := range prev {
+		best = min(best, d)
+	}
+	return best
+}
+
+// newEntry builds an entry with a completion key for every word of text.
+func newEntry(id uint, text string, likes int64) *entry {
+	e := &entry{Suggestion: Suggestion{ID: id, Text: text, Likes: likes}}
+
+	words := strings.Fields(normalize(text))
+	for i := range words {
+		e.keys = append(e.keys, strings.Join(words[i:], " "))
+	}
+	return e
+}
+
+// normalize lower-cases text, replaces punctuation with spaces and collapses whitespace.
+func normalize(text string) string {
+	mapped := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, text)
+	return strings.Join(strings.Fields(mapped), " ")
+}
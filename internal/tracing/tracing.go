@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Name identifies the instrumentation of this application in its spans.
+const Name = "github.com/Tokenzrey/FPPBKKGOLANG"
+
+// Tracer returns the tracer of the application from the global provider
+// installed by Init or Use.
+func Tracer() trace.Tracer {
+	return otel.Tracer(Name)
+}
+
+// Init installs the tracer provider exporting to the exporter selected by
+// cfg.Exporter ("none", "stdout" or "otlp") and returns the function that
+// flushes and stops it on shutdown.
+func Init(cfg config.TracingConfig) (shutdown func(context.Context) error, err error) {
+	if cfg.Exporter == "none" {
+		// Still propagate the trace context of callers to the logs
+		Use(noop.NewTracerProvider())
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := NewExporter(context.Background(), cfg, os.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := NewProvider(cfg, exporter)
+	if err != nil {
+		return nil, err
+	}
+	Use(provider)
+	return provider.Shutdown, nil
+}
+
+// NewExporter creates the exporter selected by cfg.Exporter; the stdout
+// exporter writes to w.
+func NewExporter(ctx context.Context, cfg config.TracingConfig, w io.Writer) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithWriter(w), stdouttrace.WithPrettyPrint())
+	case "otlp":
+		// An endpoint such as http://collector:4318, the path defaults to /v1/traces
+		return otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+}
+
+// NewProvider creates a tracer provider naming the service and sampling
+// cfg.SampleRatio of the traces started here, which sends the spans to
+// exporter in batches. Traces started by callers keep their decision.
+func NewProvider(cfg config.TracingConfig, exporter sdktrace.SpanExporter) (*sdktrace.TracerProvider, error) {
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	), nil
+}
+
+// Use makes provider the global tracer provider and propagates the trace
+// context in the W3C traceparent and tracestate headers.
+func Use(provider trace.TracerProvider) {
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
@@ -1,60 +1,163 @@
 package validations
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
 
-	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	id_translations "github.com/go-playground/validator/v10/translations/id"
+	"golang.org/x/text/language"
 )
 
-func IsUniqueValue(tableName, fieldName, value string) bool {
-	var count int64
+// Errors maps the JSON name of each invalid field to its messages.
+type Errors map[string][]string
 
-	result := initializers.DB.Table(tableName).Where(fieldName+" = ?", value).Count(&count)
+// Validator checks request structs against their `validate` tags and
+// reports the failures in the language of the client.
+type Validator struct {
+	validate *validator.Validate
+	uni      *ut.UniversalTranslator
+}
+
+// Supported languages, the first one being the default
+var matcher = language.NewMatcher([]language.Tag{language.English, language.Indonesian})
+
+// New creates a Validator with English and Indonesian messages.
+func New() *Validator {
+	v := &Validator{
+		validate: validator.New(validator.WithRequiredStructEnabled()),
+		uni:      ut.New(en.New(), en.New(), id.New()),
+	}
+	v.validate.RegisterTagNameFunc(jsonName)
+
+	// The bundled messages, completed with the tags they lack
+	enTrans, _ := v.uni.GetTranslator("en")
+	idTrans, _ := v.uni.GetTranslator("id")
+	must(en_translations.RegisterDefaultTranslations(v.validate, enTrans))
+	must(id_translations.RegisterDefaultTranslations(v.validate, idTrans))
+	must(v.addMessages("datetime", map[string]string{
+		"en": "{0} must be a date such as {1}",
+		"id": "{0} harus berupa tanggal seperti {1}",
+	}))
+	must(enTrans.Add("validation_failed", "Validation failed", false))
+	must(idTrans.Add("validation_failed", "Validasi gagal", false))
+	return v
+}
 
-	if result.Error != nil {
-		fmt.Println("Error:", result.Error)
-		return false
+// RegisterValidation adds a custom tag checked by fn, with its message in
+// each language ("{0}" is the field name, "{1}" the tag parameter).
+func (v *Validator) RegisterValidation(tag string, fn validator.FuncCtx, messages map[string]string) error {
+	if err := v.validate.RegisterValidationCtx(tag, fn); err != nil {
+		return err
 	}
+	return v.addMessages(tag, messages)
+}
 
-	return count > 0
+// RegisterUniqueEmail adds the unique_email tag, which fails when taken
+// reports the address as used. Lookup errors pass the check so the handler
+// can report them itself.
+func (v *Validator) RegisterUniqueEmail(taken func(ctx context.Context, email string) (bool, error)) error {
+	return v.RegisterValidation("unique_email", func(ctx context.Context, fl validator.FieldLevel) bool {
+		used, err := taken(ctx, fl.Field().String())
+		return err != nil || !used
+	}, map[string]string{
+		"en": "{0} is already registered",
+		"id": "{0} sudah terdaftar",
+	})
 }
 
-func IsExistValue(tableName, fieldName string, value interface{}) bool {
-	var count int64
+// Struct validates s and returns its failures in the language preferred by
+// the Accept-Language header lang, or nil when s is valid.
+func (v *Validator) Struct(ctx context.Context, lang string, s any) Errors {
+	var failures validator.ValidationErrors
+	if err := v.validate.StructCtx(ctx, s); !errors.As(err, &failures) {
+		if err != nil {
+			// s is not a struct, a programming error
+			panic(err)
+		}
+		return nil
+	}
 
-	result := initializers.DB.Table(tableName).Where(fieldName+" = ?", value).Count(&count)
+	trans := v.translator(lang)
+	errs := make(Errors)
+	for _, e := range failures {
+		errs[e.Field()] = append(errs[e.Field()], e.Translate(trans))
+	}
+	return errs
+}
 
-	if result.Error != nil {
-		fmt.Println("Error:", result.Error)
-		return false
+// Field returns the message of tag for field, for failures detected after
+// validation such as a concurrent signup with the same email.
+func (v *Validator) Field(lang, field, tag string) Errors {
+	msg, err := v.translator(lang).T(tag, field)
+	if err != nil {
+		msg = field + " is invalid"
 	}
+	return Errors{field: {msg}}
+}
 
-	return count > 0
+// Message returns the summary of a failed validation.
+func (v *Validator) Message(lang string) string {
+	msg, err := v.translator(lang).T("validation_failed")
+	if err != nil {
+		return "Validation failed"
+	}
+	return msg
 }
 
-func FormatValidationErrors(errs validator.ValidationErrors) map[string]string {
-	errorMessages := make(map[string]string)
+// translator picks the supported language that best matches the
+// Accept-Language header lang, English when none does.
+func (v *Validator) translator(lang string) ut.Translator {
+	tags, _, _ := language.ParseAcceptLanguage(lang)
+	tag, _, _ := matcher.Match(tags...)
+	base, _ := tag.Base()
+	trans, _ := v.uni.GetTranslator(base.String())
+	return trans
+}
 
-	for _, err := range errs {
-		fmt.Println()
-		switch err.Tag() {
-		case "required":
-			errorMessages[err.Field()] = fmt.Sprintf("%s is required", err.Field())
-		case "email":
-			errorMessages[err.Field()] = fmt.Sprintf("%s must be a valid email address", err.Field())
-		case "min":
-			errorMessages[err.Field()] = fmt.Sprintf("%s must have at least %s characters", err.Field(), err.Param())
-		case "max":
-			errorMessages[err.Field()] = fmt.Sprintf("%s must have at most %s characters", err.Field(), err.Param())
-		case "gt":
-			errorMessages[err.Field()] = fmt.Sprintf("%s must be greater than %s", err.Field(), err.Param())
-		case "gte":
-			errorMessages[err.Field()] = fmt.Sprintf("%s must be greater than or equal to %s", err.Field(), err.Param())
-		default:
-			errorMessages[err.Field()] = fmt.Sprintf("Validation validations on field %s", err.Field())
+// addMessages registers the message of tag in each language.
+func (v *Validator) addMessages(tag string, messages map[string]string) error {
+	for locale, message := range messages {
+		trans, found := v.uni.GetTranslator(locale)
+		if !found {
+			return errors.New("validations: unsupported language " + locale)
+		}
+		err := v.validate.RegisterTranslation(tag, trans,
+			func(ut ut.Translator) error { return ut.Add(tag, message, true) },
+			func(ut ut.Translator, fe validator.FieldError) string {
+				msg, err := ut.T(tag, fe.Field(), fe.Param())
+				if err != nil {
+					return fe.Error()
+				}
+				return msg
+			})
+		if err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	return errorMessages
+// jsonName reports fields by their JSON name.
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
+
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
 }
@@ -1,27 +1,59 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/Tokenzrey/FPPBKKGOLANG/api/router"
 	"github.com/Tokenzrey/FPPBKKGOLANG/config"
 	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/health"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/logging"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/mail"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/ratelimit"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/search"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/storage"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/suggest"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/tracing"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
 func init() {
-	config.LoadEnvVariables()
+	config.Init()
+	logging.Init(config.App.Log)
+	initTracing()
 	initializers.ConnectDB()
+	storage.Init(config.App.Storage)
+	search.Init(config.App.Search, initializers.DB)
+	suggest.Init(initializers.DB)
+	ratelimit.Init(config.App.RateLimit)
+	mail.Init(config.App.Mail)
 }
 
-func main() {
-	fmt.Println("BE Berhasil!")
+// stopTracing flushes the spans not exported yet.
+var stopTracing func(context.Context) error
+
+func initTracing() {
+	var err error
+	stopTracing, err = tracing.Init(config.App.Tracing)
+	if err != nil {
+		panic("Tracing initialization failed: " + err.Error())
+	}
+}
 
-	// Inisialisasi router
-	r := gin.Default()
+func main() {
+	slog.Info("Configuration", "settings", strings.Split(config.App.Redacted(), "\n"))
 
-	r.Static("/uploads", "./uploads")
+	// Inisialisasi router; the routes install their own request logging and panic recovery
+	r := gin.New()
 
 	// Middleware CORS: Mengizinkan semua origin
 	r.Use(cors.New(cors.Config{
@@ -36,5 +68,52 @@ func main() {
 	router.GetRoute(r)
 
 	// Jalankan server
-	r.Run()
+	cfg := config.App.Server
+	srv := &http.Server{
+		Addr:              config.App.Addr(),
+		Handler:           r,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		slog.Info("Listening", "addr", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Server failed", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	<-ctx.Done()
+	stop() // A second signal kills the process immediately
+	shutdown(srv, cfg)
+}
+
+// shutdown fails readiness first so load balancers stop sending traffic,
+// then drains in-flight requests within the shutdown timeout and finally
+// closes the database pool and flushes the traces.
+func shutdown(srv *http.Server, cfg config.ServerConfig) {
+	slog.Info("Shutting down", "drain_delay", cfg.DrainDelay)
+	health.SetDraining(true)
+	time.Sleep(cfg.DrainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Warn("Forced shutdown, some requests were cut off", "error", err)
+	}
+
+	if err := initializers.CloseDB(); err != nil {
+		slog.Error("Closing the database failed", "error", err)
+	}
+	if err := stopTracing(ctx); err != nil {
+		slog.Error("Flushing the traces failed", "error", err)
+	}
+	slog.Info("Server stopped")
 }
@@ -0,0 +1,203 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/helpers"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/imaging"
+)
+
+func TestBlogCRUD(t *testing.T) {
+	s := NewServer(t)
+	_, author := s.NewUser("budi")
+	_, reader := s.NewUser("siti")
+
+	// Publishing needs a token
+	fields := map[string]string{"judul": "Belajar Golang", "content": "Tutorial dasar"}
+	s.Upload("/api/blogs/", "", fields, "thumbnail.png", encodePNG(t, 64, 48)).Expect(http.StatusUnauthorized)
+
+	var created struct {
+		Blog struct {
+			ID        uint   `json:"id"`
+			Thumbnail string `json:"thumbnail"`
+		} `json:"blog"`
+	}
+	s.Upload("/api/blogs/", author, fields, "thumbnail.png", encodePNG(t, 64, 48)).Expect(http.StatusOK).Data(&created)
+	thumbnail := strings.TrimPrefix(created.Blog.Thumbnail, "/uploads/")
+	if !strings.HasSuffix(thumbnail, ".png") {
+		t.Fatalf("unexpected thumbnail URL %q", created.Blog.Thumbnail)
+	}
+
+	// The image and its variants are written to the upload directory and served
+	files := []string{thumbnail}
+	for _, width := range imaging.Widths {
+		files = append(files, imaging.VariantName(thumbnail, width))
+	}
+	for _, name := range files {
+		if _, err := os.Stat(filepath.Join(s.UploadDir, name)); err != nil {
+			t.Fatalf("expected %s to be stored: %v", name, err)
+		}
+	}
+	if w := s.Get(created.Blog.Thumbnail, ""); w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("serving the thumbnail: got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	// Reading a blog
+	path := fmt.Sprintf("/api/blog/%d", created.Blog.ID)
+	var blog struct {
+		Title  string `json:"title"`
+		Author struct {
+			Name string `json:"name"`
+		} `json:"author"`
+		Likes struct {
+			Count     int64 `json:"count"`
+			UserLiked bool  `json:"userLiked"`
+		} `json:"likes"`
+	}
+	s.Get(path, reader).Expect(http.StatusOK).Data(&blog)
+	if blog.Title != "Belajar Golang" || blog.Author.Name != "budi" || blog.Likes.Count != 0 || blog.Likes.UserLiked {
+		t.Fatalf("unexpected blog: %+v", blog)
+	}
+	s.Get("/api/blog/999", reader).Expect(http.StatusNotFound)
+
+	// Only the author deletes a blog, which removes its files
+	deletePath := fmt.Sprintf("/api/blogs/%d", created.Blog.ID)
+	s.Do(http.MethodDelete, deletePath, nil, "", reader).Expect(http.StatusForbidden)
+	s.Do(http.MethodDelete, deletePath, nil, "", author).Expect(http.StatusOK)
+	s.Do(http.MethodDelete, deletePath, nil, "", author).Expect(http.StatusNotFound)
+	s.Get(path, reader).Expect(http.StatusNotFound)
+	for _, name := range files {
+		if _, err := os.Stat(filepath.Join(s.UploadDir, name)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be deleted, got %v", name, err)
+		}
+	}
+}
+
+func TestSharedThumbnail(t *testing.T) {
+	s := NewServer(t)
+	_, token := s.NewUser("budi")
+
+	// Identical uploads are stored once and kept until no blog uses them
+	first := s.PostBlog(token, "Pertama", "Isi pertama")
+	second := s.PostBlog(token, "Kedua", "Isi kedua")
+	entries, _ := os.ReadDir(s.UploadDir)
+	if len(entries) != 1+len(imaging.Widths) {
+		t.Fatalf("expected one stored upload with its variants, got %d files", len(entries))
+	}
+
+	s.Do(http.MethodDelete, fmt.Sprintf("/api/blogs/%d", first), nil, "", token).Expect(http.StatusOK)
+	if entries, _ := os.ReadDir(s.UploadDir); len(entries) != 1+len(imaging.Widths) {
+		t.Fatalf("the thumbnail of the remaining blog was deleted")
+	}
+	s.Do(http.MethodDelete, fmt.Sprintf("/api/blogs/%d", second), nil, "", token).Expect(http.StatusOK)
+	if entries, _ := os.ReadDir(s.UploadDir); len(entries) != 0 {
+		t.Fatalf("expected the unused thumbnail to be deleted, %d files left", len(entries))
+	}
+}
+
+func TestSharedThumbnailRestored(t *testing.T) {
+	s := NewServer(t)
+	_, token := s.NewUser("budi")
+
+	// A Delete racing with the upload may remove the files of an identical
+	// upload after they were found; posting writes them again
+	s.PostBlog(token, "Pertama", "Isi pertama")
+	entries, _ := os.ReadDir(s.UploadDir)
+	for _, entry := range entries {
+		os.Remove(filepath.Join(s.UploadDir, entry.Name()))
+	}
+	s.PostBlog(token, "Kedua", "Isi kedua")
+	if entries, _ := os.ReadDir(s.UploadDir); len(entries) != 1+len(imaging.Widths) {
+		t.Fatalf("expected the upload to be stored again, got %d files", len(entries))
+	}
+}
+
+func TestPostBlogValidation(t *testing.T) {
+	s := NewServer(t)
+	_, token := s.NewUser("budi")
+	fields := map[string]string{"judul": "Judul", "content": "Content"}
+
+	for _, tc := range []struct {
+		name     string
+		fields   map[string]string
+		fileName string
+		content  []byte
+		message  string
+	}{
+		{"missing fields", map[string]string{"judul": "Judul"}, "a.png", encodePNG(t, 8, 8), "Judul and content are required"},
+		{"missing thumbnail", fields, "", nil, "Thumbnail image is required"},
+		{"not an image", fields, "a.png", []byte("plain text, not an image"), "Invalid file type"},
+		{"corrupted image", fields, "a.png", encodePNG(t, 8, 8)[:40], "Invalid or corrupted image file"},
+		{"too big", fields, "a.png", make([]byte, 3*1024*1024+1), "File size exceeds the 3MB limit"},
+	} {
+		w := s.Upload("/api/blogs/", token, tc.fields, tc.fileName, tc.content)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tc.message) {
+			t.Errorf("%s: expected 400 %q, got %d %s", tc.name, tc.message, w.Code, w.Body)
+		}
+	}
+
+	if entries, _ := os.ReadDir(s.UploadDir); len(entries) != 0 {
+		t.Fatalf("refused uploads left %d files behind", len(entries))
+	}
+}
+
+func TestBlogPagination(t *testing.T) {
+	s := NewServer(t)
+	_, token := s.NewUser("budi")
+	_, reader := s.NewUser("siti")
+	ids := make([]uint, 5)
+	for i := range ids {
+		ids[i] = s.PostBlog(token, fmt.Sprintf("Blog %d", i+1), "Content")
+	}
+	// The third blog gets a like, the fourth two comments
+	s.JSON(http.MethodPost, "/like", reader, map[string]uint{"blog_id": ids[2]}).Expect(http.StatusCreated)
+	for range 2 {
+		s.JSON(http.MethodPost, "/comment", reader, map[string]any{"blog_id": ids[3], "comment": "Bagus sekali"}).Expect(http.StatusCreated)
+	}
+
+	type page struct {
+		Data []struct {
+			ID uint `json:"ID"`
+		} `json:"data"`
+		Meta helpers.Meta `json:"meta"`
+	}
+	list := func(query string) page {
+		t.Helper()
+		var p page
+		s.Get("/api/blogs?"+query, "").Expect(http.StatusOK).Decode(&p)
+		return p
+	}
+
+	// Newest first by default, split over pages
+	first := list("perPage=2")
+	if first.Meta.Total != 5 || first.Meta.LastPage != 3 || len(first.Data) != 2 || first.Data[0].ID != ids[4] {
+		t.Fatalf("unexpected first page: %+v", first)
+	}
+	if last := list("perPage=2&page=3"); len(last.Data) != 1 || last.Data[0].ID != ids[0] {
+		t.Fatalf("unexpected last page: %+v", last)
+	}
+	if beyond := list("perPage=2&page=4"); len(beyond.Data) != 0 || beyond.Meta.Total != 5 {
+		t.Fatalf("expected an empty page past the end, got %+v", beyond)
+	}
+
+	// Sorting by the counters
+	if liked := list("sort=likes"); liked.Data[0].ID != ids[2] {
+		t.Fatalf("expected the liked blog first, got %+v", liked.Data)
+	}
+	if commented := list("sort=comments"); commented.Data[0].ID != ids[3] {
+		t.Fatalf("expected the commented blog first, got %+v", commented.Data)
+	}
+
+	// Invalid parameters are refused
+	for _, query := range []string{"page=0", "page=-1", "page=abc", "perPage=0", "perPage=101", "sort=views"} {
+		s.Get("/api/blogs?"+query, "").Expect(http.StatusBadRequest)
+	}
+	if max := list("perPage=100"); len(max.Data) != 5 || max.Meta.PerPage != 100 {
+		t.Fatalf("unexpected page with perPage=100: %+v", max)
+	}
+}
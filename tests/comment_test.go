@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestComments(t *testing.T) {
+	s := NewServer(t)
+	_, author := s.NewUser("budi")
+	_, reader := s.NewUser("siti")
+	blogID := s.PostBlog(author, "Belajar Golang", "Content")
+
+	post := func(token string, body any) *Response {
+		return s.JSON(http.MethodPost, "/comment", token, body)
+	}
+	post(reader, map[string]any{"blog_id": blogID, "comment": "Tulisan yang bagus"}).Expect(http.StatusCreated)
+	post(author, map[string]any{"blog_id": blogID, "comment": "Terima kasih"}).Expect(http.StatusCreated)
+
+	// Validation, missing blogs and missing tokens
+	post(reader, map[string]any{"blog_id": blogID, "comment": "hai"}).Expect(http.StatusUnprocessableEntity)
+	post(reader, map[string]any{"comment": "Tanpa blog"}).Expect(http.StatusUnprocessableEntity)
+	post(reader, map[string]any{"blog_id": 999, "comment": "Blog tidak ada"}).Expect(http.StatusNotFound)
+	post("", map[string]any{"blog_id": blogID, "comment": "Tanpa token"}).Expect(http.StatusUnauthorized)
+
+	type listing struct {
+		Comments []struct {
+			ID       uint   `json:"id"`
+			Comment  string `json:"comment"`
+			UserName string `json:"user_name"`
+		} `json:"comments"`
+		Count int `json:"count"`
+	}
+	list := func() listing {
+		t.Helper()
+		var l listing
+		s.Get(fmt.Sprintf("/api/blogs/comment/%d", blogID), reader).Expect(http.StatusOK).Data(&l)
+		return l
+	}
+
+	// Newest first, with the author's name
+	comments := list()
+	if comments.Count != 2 || comments.Comments[0].Comment != "Terima kasih" || comments.Comments[1].UserName != "siti" {
+		t.Fatalf("unexpected comments: %+v", comments)
+	}
+	s.Get("/api/blogs/comment/999", reader).Expect(http.StatusNotFound)
+	s.Get("/api/blogs/comment/abc", reader).Expect(http.StatusBadRequest)
+
+	// The blog shows the same comments, without the commenters' details
+	var blog struct {
+		Comments []map[string]any `json:"comments"`
+	}
+	s.Get(fmt.Sprintf("/api/blog/%d", blogID), reader).Expect(http.StatusOK).Data(&blog)
+	if len(blog.Comments) != 2 || blog.Comments[1]["user_name"] != "siti" {
+		t.Fatalf("unexpected blog comments: %+v", blog.Comments)
+	}
+	for _, comment := range blog.Comments {
+		if _, ok := comment["user"]; ok || len(comment) != 5 {
+			t.Fatalf("the comment leaks its author's details: %+v", comment)
+		}
+	}
+
+	// Only the author deletes a comment, and the counter follows
+	readerComment := fmt.Sprintf("/comment/%d", comments.Comments[1].ID)
+	s.Do(http.MethodDelete, readerComment, nil, "", author).Expect(http.StatusForbidden)
+	s.Do(http.MethodDelete, readerComment, nil, "", reader).Expect(http.StatusOK)
+	s.Do(http.MethodDelete, readerComment, nil, "", reader).Expect(http.StatusNotFound)
+	if comments := list(); comments.Count != 1 {
+		t.Fatalf("expected one comment left, got %+v", comments)
+	}
+
+	var blogs []struct {
+		CommentCount int64 `json:"comment_count"`
+	}
+	s.Get("/api/blogs", "").Expect(http.StatusOK).Data(&blogs)
+	if len(blogs) != 1 || blogs[0].CommentCount != 1 {
+		t.Fatalf("expected the stored counter to be 1, got %+v", blogs)
+	}
+}
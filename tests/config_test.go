@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/config"
+)
+
+func TestLoadConfig(t *testing.T) {
+	// Values from the config file are overridden by the environment
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(file, []byte("port: \"8080\"\ndatabase:\n  dsn: user:hunter2@tcp(db:3306)/blog\nsearch:\n  backend: memory\n"), 0o644)
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("SECRET", strings.Repeat("s", config.MinSecretLength))
+	t.Setenv("PORT", "9090")
+
+	cfg, err := config.Load(filepath.Join(t.TempDir(), "missing.env"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Port != "9090" || cfg.Search.Backend != "memory" || cfg.Storage.Driver != "local" {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+
+	// Secrets never reach the startup log
+	dump := cfg.Redacted()
+	if strings.Contains(dump, "hunter2") || strings.Contains(dump, "sss") {
+		t.Fatalf("secrets leaked:\n%s", dump)
+	}
+	if !strings.Contains(dump, "DNS=user:********@tcp(db:3306)/blog") {
+		t.Fatalf("expected a redacted DSN:\n%s", dump)
+	}
+
+	// Trusted proxies are IPs or CIDRs
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.0.2.1")
+	if cfg, err := config.Load(filepath.Join(t.TempDir(), "missing.env")); err != nil || len(cfg.Server.Proxies()) != 2 || cfg.Server.Proxies()[1] != "192.0.2.1" {
+		t.Fatalf("unexpected trusted proxies: %v", err)
+	}
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8,proxy.local")
+	if _, err := config.Load(filepath.Join(t.TempDir(), "missing.env")); err == nil || !strings.Contains(err.Error(), "TRUSTED_PROXIES") {
+		t.Fatalf("expected a TRUSTED_PROXIES validation error, got %v", err)
+	}
+	t.Setenv("TRUSTED_PROXIES", "")
+
+	// A short secret is refused
+	t.Setenv("SECRET", "short")
+	if _, err := config.Load(filepath.Join(t.TempDir(), "missing.env")); err == nil || !strings.Contains(err.Error(), "SECRET") {
+		t.Fatalf("expected a SECRET validation error, got %v", err)
+	}
+}
+
+func TestLoadTOMLDurations(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(file, []byte("[database]\ndsn = \"blog.db\"\ndriver = \"sqlite\"\nmax_open_conns = 5\nconn_max_lifetime = \"90s\"\n"), 0o644)
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("SECRET", strings.Repeat("s", config.MinSecretLength))
+
+	cfg, err := config.Load(filepath.Join(t.TempDir(), "missing.env"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Database.MaxOpenConns != 5 || cfg.Database.ConnMaxLifetime != 90*time.Second || cfg.Database.MaxIdleConns != 10 {
+		t.Fatalf("unexpected database config %+v", cfg.Database)
+	}
+
+	// Negative pool settings are refused
+	t.Setenv("DB_MAX_IDLE_CONNS", "-1")
+	if _, err := config.Load(filepath.Join(t.TempDir(), "missing.env")); err == nil || !strings.Contains(err.Error(), "DB_MAX_IDLE_CONNS") {
+		t.Fatalf("expected a DB_MAX_IDLE_CONNS validation error, got %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/config"
+	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
+)
+
+func TestConnectRetries(t *testing.T) {
+	// The directory does not exist, so every attempt fails
+	cfg := config.DatabaseConfig{
+		Driver:         "sqlite",
+		DSN:            filepath.Join(t.TempDir(), "missing", "blog.db"),
+		ConnectRetries: 2,
+		RetryBackoff:   time.Millisecond,
+	}
+	if _, err := initializers.Connect(cfg); err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Fatalf("expected the connection to fail after 3 attempts, got %v", err)
+	}
+
+	// The pool is configured once connected
+	cfg.DSN = filepath.Join(t.TempDir(), "blog.db")
+	cfg.MaxOpenConns = 4
+	db, err := initializers.Connect(cfg)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+	if max := sqlDB.Stats().MaxOpenConnections; max != 4 {
+		t.Fatalf("expected 4 max open connections, got %d", max)
+	}
+}
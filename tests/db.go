@@ -1,33 +1,63 @@
 package tests
 
 import (
-	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
-	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
-	"github.com/joho/godotenv"
+	"context"
 	"log"
+	"os"
+	"strings"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/config"
+	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
+	"github.com/Tokenzrey/FPPBKKGOLANG/db/migrations"
 )
 
-// DatabaseRefresh runs fresh migration
-func DatabaseRefresh() {
-	// Load env
-	err := godotenv.Load("../.env")
-	if err != nil {
-		log.Fatal("Error loading .env file")
-	}
+// TestDSN is the default test database: an in-memory SQLite database shared
+// by all connections of the pool, so no external server is needed.
+const TestDSN = "file::memory:?cache=shared&_pragma=foreign_keys(1)"
 
-	// Connect DB
-	initializers.ConnectDB()
+// TestConfig returns the configuration used by the tests. The database can
+// be switched with TEST_DB_DRIVER and TEST_DB_DSN to run the suite against
+// MySQL or PostgreSQL; .env is deliberately not read so the tests never drop
+// the tables of a development database.
+func TestConfig() *config.Config {
+	cfg := &config.Config{
+		Env:    "test",
+		Port:   "3000",
+		Secret: strings.Repeat("t", config.MinSecretLength),
+		Database: config.DatabaseConfig{
+			Driver: "sqlite",
+			DSN:    TestDSN,
+			// The in-memory database is dropped with its last connection,
+			// so keep idle connections around forever
+			MaxIdleConns: 2,
+		},
+		Storage: config.StorageConfig{Driver: "local", URLPrefix: "/uploads"},
+		Search:  config.SearchConfig{Backend: "sql"},
+	}
 
-	// Drop all the tables
-	err = initializers.DB.Migrator().DropTable(models.User{}, models.Like{}, models.Blog{}, models.Comment{})
-	if err != nil {
-		log.Fatal("Table dropping failed")
+	if driver := os.Getenv("TEST_DB_DRIVER"); driver != "" {
+		cfg.Database.Driver = driver
+		cfg.Database.DSN = os.Getenv("TEST_DB_DSN")
 	}
 
-	// Migrate again
-	err = initializers.DB.AutoMigrate(models.User{}, models.Like{}, models.Blog{}, models.Comment{})
+	return cfg
+}
+
+// DatabaseRefresh runs fresh migration
+func DatabaseRefresh() {
+	// Load the test configuration
+	config.App = TestConfig()
+
+	// Connect DB, closing the pool of the previous test so none leaks
+	if initializers.DB != nil {
+		if err := initializers.CloseDB(); err != nil {
+			log.Fatal("Closing the database failed: ", err)
+		}
+	}
+	initializers.ConnectDB()
 
-	if err != nil {
-		log.Fatal("Migration failed")
+	// Drop all the tables and migrate again
+	if _, err := migrations.New(initializers.DB).Fresh(context.Background()); err != nil {
+		log.Fatal("Migration failed: ", err)
 	}
 }
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/api/router"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/health"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadiness(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	DatabaseRefresh()
+	dir := t.TempDir()
+	local, err := storage.NewLocal(dir, "/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.Default = local
+
+	r := gin.New()
+	router.GetRoute(r)
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	if w := get("/healthz"); w.Code != http.StatusOK {
+		t.Fatalf("healthz: expected 200, got %d", w.Code)
+	}
+	w := get("/readyz")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"database":{"status":"up"`) || !strings.Contains(w.Body.String(), `"storage":{"status":"up"`) {
+		t.Fatalf("readyz: expected ready, got %d %s", w.Code, w.Body)
+	}
+
+	// Storage that cannot be written to fails readiness
+	os.RemoveAll(dir)
+	os.WriteFile(dir, []byte("not a directory"), 0o644)
+	if w := get("/readyz"); w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), `"storage":{"status":"down"`) {
+		t.Fatalf("readyz with unwritable storage: expected 503, got %d %s", w.Code, w.Body)
+	}
+	os.Remove(dir)
+	os.Mkdir(dir, 0o755)
+
+	// Draining fails readiness but not liveness
+	health.SetDraining(true)
+	defer health.SetDraining(false)
+	if w := get("/readyz"); w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "draining") {
+		t.Fatalf("readyz while draining: expected 503, got %d %s", w.Code, w.Body)
+	}
+	if w := get("/healthz"); w.Code != http.StatusOK {
+		t.Fatalf("healthz while draining: expected 200, got %d", w.Code)
+	}
+}
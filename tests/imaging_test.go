@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/imaging"
+)
+
+func TestProcessImage(t *testing.T) {
+	// A valid PNG produces a PNG original and every variant
+	result, err := imaging.Process(encodePNG(t, 2000, 1000))
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if result.Extension() != ".png" || len(result.Variants) != len(imaging.Widths) {
+		t.Fatalf("unexpected result: %s with %d variants", result.Extension(), len(result.Variants))
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(result.Variants[320]))
+	if err != nil || cfg.Width != 320 || cfg.Height != 160 {
+		t.Fatalf("unexpected 320w variant: %+v, %v", cfg, err)
+	}
+
+	// GIF uploads are accepted and stored as PNG
+	var gifBuf bytes.Buffer
+	gif.Encode(&gifBuf, image.NewPaletted(image.Rect(0, 0, 10, 10), []color.Color{color.Black}), nil)
+	result, err = imaging.Process(gifBuf.Bytes())
+	if err != nil || result.Extension() != ".png" {
+		t.Fatalf("expected GIF to be stored as PNG, got %v", err)
+	}
+
+	// Data appended after the end of the image is a polyglot
+	polyglot := append(encodePNG(t, 10, 10), []byte("<html><script>alert(1)</script></html>")...)
+	if _, err := imaging.Process(polyglot); !errors.Is(err, imaging.ErrInvalidImage) {
+		t.Fatalf("expected ErrInvalidImage for polyglot, got %v", err)
+	}
+
+	// Dimensions are checked before any pixel is decoded
+	if _, err := imaging.Process(encodePNG(t, imaging.MaxDimension+1, 1)); !errors.Is(err, imaging.ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+
+	// Non-image content is rejected
+	if _, err := imaging.Process([]byte("<html></html>")); !errors.Is(err, imaging.ErrUnsupportedFormat) {
+		t.Fatalf("expected ErrUnsupportedFormat, got %v", err)
+	}
+}
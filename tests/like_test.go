@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/api/router"
+	"github.com/Tokenzrey/FPPBKKGOLANG/config"
+	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/counters"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+func TestToggleLike(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	DatabaseRefresh()
+
+	user := models.User{Name: "Liker", Email: "liker@example.com"}
+	initializers.DB.Create(&user)
+	blog := models.Blog{Judul: "Judul", Content: "Content", UserID: user.ID}
+	initializers.DB.Create(&blog)
+
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": user.ID,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(config.App.Secret))
+
+	r := gin.New()
+	router.GetRoute(r)
+	toggle := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/like", strings.NewReader(`{"blog_id": 1}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	count := func() int64 {
+		var n int64
+		initializers.DB.Unscoped().Model(&models.Like{}).Count(&n)
+		return n
+	}
+
+	// Like, unlike and like again: the row is deleted for good in between
+	for i, want := range []struct {
+		code  int
+		liked string
+		rows  int64
+	}{
+		{http.StatusCreated, `"liked":true`, 1},
+		{http.StatusOK, `"liked":false`, 0},
+		{http.StatusCreated, `"liked":true`, 1},
+	} {
+		w := toggle()
+		if w.Code != want.code || !strings.Contains(w.Body.String(), want.liked) || count() != want.rows {
+			t.Fatalf("toggle %d: got %d %s with %d rows", i+1, w.Code, w.Body, count())
+		}
+		initializers.DB.First(&blog, blog.ID)
+		if blog.LikeCount != want.rows {
+			t.Fatalf("toggle %d: like counter is %d, expected %d", i+1, blog.LikeCount, want.rows)
+		}
+	}
+
+	// The database refuses a duplicate like
+	err := initializers.DB.Create(&models.Like{UserID: user.ID, BlogID: blog.ID}).Error
+	if !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Fatalf("expected a duplicate key error, got %v", err)
+	}
+}
+
+func TestReconcileCounters(t *testing.T) {
+	DatabaseRefresh()
+
+	user := models.User{Name: "Writer", Email: "writer@example.com"}
+	initializers.DB.Create(&user)
+	blog := models.Blog{Judul: "Judul", Content: "Content", UserID: user.ID}
+	initializers.DB.Create(&blog)
+	initializers.DB.Create(&models.Like{UserID: user.ID, BlogID: blog.ID})
+	initializers.DB.Create(&[]models.Comment{
+		{Comment: "first comment", UserID: user.ID, BlogID: blog.ID},
+		{Comment: "second comment", UserID: user.ID, BlogID: blog.ID},
+	})
+	// A deleted comment is not counted
+	initializers.DB.Delete(&models.Comment{}, "comment = ?", "second comment")
+
+	fixed, err := counters.Reconcile(initializers.DB)
+	if err != nil || fixed != 1 {
+		t.Fatalf("expected one blog fixed, got %d: %v", fixed, err)
+	}
+	initializers.DB.First(&blog, blog.ID)
+	if blog.LikeCount != 1 || blog.CommentCount != 1 {
+		t.Fatalf("unexpected counters: %d likes, %d comments", blog.LikeCount, blog.CommentCount)
+	}
+
+	// Consistent counters are left alone
+	if fixed, err := counters.Reconcile(initializers.DB); err != nil || fixed != 0 {
+		t.Fatalf("expected nothing to fix, got %d: %v", fixed, err)
+	}
+}
+
+func TestLikeStatus(t *testing.T) {
+	s := NewServer(t)
+	_, author := s.NewUser("budi")
+	_, reader := s.NewUser("siti")
+	blogID := s.PostBlog(author, "Belajar Golang", "Content")
+
+	type status struct {
+		LikesCount  int64 `json:"likes_count"`
+		LikedByUser bool  `json:"liked_by_user"`
+	}
+	check := func(token string, want status) {
+		t.Helper()
+		var got status
+		s.Get(fmt.Sprintf("/api/blogs/like/%d", blogID), token).Expect(http.StatusOK).Data(&got)
+		if got != want {
+			t.Fatalf("expected %+v, got %+v", want, got)
+		}
+	}
+
+	like := map[string]uint{"blog_id": blogID}
+	s.JSON(http.MethodPost, "/like", "", like).Expect(http.StatusUnauthorized)
+	s.JSON(http.MethodPost, "/like", reader, like).Expect(http.StatusCreated)
+	s.JSON(http.MethodPost, "/like", author, like).Expect(http.StatusCreated)
+	check(reader, status{LikesCount: 2, LikedByUser: true})
+
+	s.JSON(http.MethodPost, "/like", reader, like).Expect(http.StatusOK)
+	check(reader, status{LikesCount: 1, LikedByUser: false})
+	check(author, status{LikesCount: 1, LikedByUser: true})
+
+	// Missing blogs and malformed requests
+	s.JSON(http.MethodPost, "/like", reader, map[string]uint{"blog_id": 999}).Expect(http.StatusNotFound)
+	s.JSON(http.MethodPost, "/like", reader, `{}`).Expect(http.StatusUnprocessableEntity)
+	s.Get("/api/blogs/like/999", reader).Expect(http.StatusNotFound)
+	s.Get("/api/blogs/like/abc", reader).Expect(http.StatusBadRequest)
+}
@@ -0,0 +1,269 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/api/router"
+	"github.com/Tokenzrey/FPPBKKGOLANG/config"
+	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/helpers"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/mail"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/repository"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// outbox records the emails instead of sending them.
+type outbox struct {
+	mu   sync.Mutex
+	sent []mail.Message
+}
+
+func (o *outbox) Send(_ context.Context, msg mail.Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.sent = append(o.sent, msg)
+	return nil
+}
+
+func (o *outbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.sent)
+}
+
+// Await returns the emails once n were sent in the background, failing the
+// test if they take longer than a second.
+func (o *outbox) Await(t *testing.T, n int) []mail.Message {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); o.Len() < n && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.sent) != n {
+		t.Fatalf("expected %d emails, got %+v", n, o.sent)
+	}
+	return append([]mail.Message(nil), o.sent...)
+}
+
+func TestLoginLockout(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+	sent := &outbox{}
+	users := service.New(repos, service.Options{
+		Secret:  strings.Repeat("t", 32),
+		Lockout: service.Lockout{Threshold: 3, Duration: time.Second, MaxDuration: 1500 * time.Millisecond},
+		Mailer:  sent,
+	}).Users
+	user, err := users.Signup(ctx, service.Profile{Name: "Budi", Email: "budi@example.com"}, "secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	login := func(password string) error {
+		_, err := users.Login(ctx, "budi@example.com", password, "192.0.2.1")
+		return err
+	}
+	// failUntilLocked returns how long the account is locked for
+	failUntilLocked := func() time.Duration {
+		t.Helper()
+		var start time.Time
+		for i := 1; i <= 3; i++ {
+			start = time.Now()
+			if err := login("wrong"); !errors.Is(err, service.ErrInvalidCredentials) {
+				t.Fatalf("failure %d: expected ErrInvalidCredentials, got %v", i, err)
+			}
+		}
+		stored, _ := repos.Users.FindByID(ctx, user.ID)
+		if stored.LockedUntil == nil {
+			t.Fatalf("expected the third failure to lock the account: %+v", stored)
+		}
+		return stored.LockedUntil.Sub(start)
+	}
+
+	// The third failure in a row locks the account, even for the right password
+	if d := failUntilLocked(); d < time.Second || d > 1100*time.Millisecond {
+		t.Fatalf("unexpected first lockout of %v", d)
+	}
+	if err := login("secret123"); !errors.Is(err, service.ErrInvalidCredentials) {
+		t.Fatalf("expected the locked account to refuse the password, got %v", err)
+	}
+	if notices := sent.Await(t, 1); notices[0].To != "budi@example.com" || !strings.Contains(notices[0].Body, "locked until") {
+		t.Fatalf("unexpected lockout notice: %+v", notices[0])
+	}
+
+	// The next lockout doubles, up to the maximum
+	time.Sleep(time.Second)
+	if d := failUntilLocked(); d < 1500*time.Millisecond || d > 1600*time.Millisecond {
+		t.Fatalf("expected the second lockout to reach the 1.5s maximum, got %v", d)
+	}
+	sent.Await(t, 2) // A notice per lockout
+
+	// A successful login clears the failures and records the client
+	time.Sleep(1500 * time.Millisecond)
+	if err := login("secret123"); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := repos.Users.FindByID(ctx, user.ID)
+	if stored.FailedLogins != 0 || stored.Lockouts != 0 || stored.LockedUntil != nil || stored.LastLoginAt == nil || stored.LastLoginIP != "192.0.2.1" {
+		t.Fatalf("unexpected login state: %+v", stored)
+	}
+	if err := login("wrong"); !errors.Is(err, service.ErrInvalidCredentials) {
+		t.Fatalf("expected a fresh count after the login, got %v", err)
+	}
+}
+
+// slowMailer blocks until released, then reports the state of its context.
+type slowMailer struct {
+	release chan struct{}
+	done    chan error
+}
+
+func (m slowMailer) Send(ctx context.Context, _ mail.Message) error {
+	<-m.release
+	if _, ok := ctx.Deadline(); !ok {
+		m.done <- errors.New("no deadline")
+	} else {
+		m.done <- ctx.Err()
+	}
+	return nil
+}
+
+func TestLockoutNoticeInBackground(t *testing.T) {
+	mailer := slowMailer{release: make(chan struct{}), done: make(chan error, 1)}
+	users := service.New(repository.NewMemory(), service.Options{
+		Secret:  strings.Repeat("t", 32),
+		Lockout: service.Lockout{Threshold: 1, Duration: time.Minute, MaxDuration: time.Hour},
+		Mailer:  mailer,
+	}).Users
+	if _, err := users.Signup(context.Background(), service.Profile{Name: "Budi", Email: "budi@example.com"}, "secret123"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The login is answered while the mail server is still busy, and the
+	// notice is sent after the request is over
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := users.Login(ctx, "budi@example.com", "wrong", "192.0.2.1"); !errors.Is(err, service.ErrInvalidCredentials) {
+		t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+	}
+	cancel()
+	close(mailer.release)
+	if err := <-mailer.done; err != nil {
+		t.Fatalf("the notice was not sent with a live, bounded context: %v", err)
+	}
+}
+
+func TestLoginTiming(t *testing.T) {
+	ctx := context.Background()
+	users := memoryServices(t).Users
+	if _, err := users.Signup(ctx, service.Profile{Name: "Budi", Email: "budi@example.com"}, "secret123"); err != nil {
+		t.Fatal(err)
+	}
+	timeLogin := func(email string) time.Duration {
+		start := time.Now()
+		if _, err := users.Login(ctx, email, "wrong", "192.0.2.1"); !errors.Is(err, service.ErrInvalidCredentials) {
+			t.Fatalf("%s: expected ErrInvalidCredentials, got %v", email, err)
+		}
+		return time.Since(start)
+	}
+	timeLogin("nobody@example.com") // Hashes the dummy password once
+
+	// An unknown email is refused only after hashing, like a wrong password
+	unknown, wrong := timeLogin("nobody@example.com"), timeLogin("budi@example.com")
+	if unknown < wrong/2 {
+		t.Fatalf("unknown emails are refused faster: %v against %v", unknown, wrong)
+	}
+}
+
+func TestLoginLockoutHTTP(t *testing.T) {
+	sent := &outbox{}
+	mail.Default = sent
+	defer func() { mail.Default = nil }()
+
+	s := NewServer(t)
+	config.App.Lockout = config.LockoutConfig{Threshold: 2, Duration: time.Minute, MaxDuration: time.Hour}
+	s.Engine = gin.New()
+	router.GetRoute(s.Engine)
+	userID, token := s.NewUser("budi")
+
+	var user models.User
+	initializers.DB.First(&user, userID)
+	if user.LastLoginAt == nil || user.LastLoginIP != "192.0.2.1" {
+		t.Fatalf("the login was not recorded: %+v", user)
+	}
+
+	// Only the owner sees when they last logged in, not the readers of their blogs
+	s.PostBlog(token, "Judul", "Content")
+	if w := s.Get("/api/blogs", "").Expect(http.StatusOK); strings.Contains(w.Body.String(), "last_login") {
+		t.Fatalf("the last login leaks in the blog list: %s", w.Body)
+	}
+	if w := s.Get("/api/users/", token).Expect(http.StatusOK); !strings.Contains(w.Body.String(), `"last_login_at":"`) {
+		t.Fatalf("expected the last login in the profile: %s", w.Body)
+	}
+
+	wrong := map[string]string{"email": "budi@example.com", "password": "wrong"}
+	s.JSON(http.MethodPost, "/api/login", "", wrong).Expect(http.StatusUnauthorized)
+	s.JSON(http.MethodPost, "/api/login", "", wrong).Expect(http.StatusUnauthorized)
+	initializers.DB.First(&user, userID)
+	if user.LockedUntil == nil || time.Until(*user.LockedUntil) < 59*time.Second {
+		t.Fatalf("expected the account to be locked for a minute: %+v", user)
+	}
+	sent.Await(t, 1)
+
+	// A locked account answers like an unknown email, even to the right
+	// password, so clients cannot tell which emails are registered
+	unknown := s.JSON(http.MethodPost, "/api/login", "", map[string]string{"email": "nobody@example.com", "password": "secret123"})
+	unknown.Expect(http.StatusUnauthorized)
+	for _, password := range []string{"wrong", "secret123"} {
+		w := s.JSON(http.MethodPost, "/api/login", "", map[string]string{"email": "budi@example.com", "password": password})
+		if w.Code != unknown.Code || w.Body.String() != unknown.Body.String() || w.Header().Get("Retry-After") != "" {
+			t.Fatalf("the locked account answers %d %v %s, an unknown email %d %s", w.Code, w.Header(), w.Body, unknown.Code, unknown.Body)
+		}
+	}
+	var body helpers.APIResponse
+	unknown.Decode(&body)
+	if body.Code != helpers.CodeInvalidCredentials {
+		t.Fatalf("unexpected response: %s", unknown.Body)
+	}
+	sent.Await(t, 1) // Refused logins do not extend the lock or notify again
+}
+
+func TestLoginLockoutConcurrent(t *testing.T) {
+	sent := &outbox{}
+	mail.Default = sent
+	defer func() { mail.Default = nil }()
+
+	s := NewServer(t)
+	config.App.Lockout = config.LockoutConfig{Threshold: 3, Duration: time.Minute, MaxDuration: time.Hour}
+	s.Engine = gin.New()
+	router.GetRoute(s.Engine)
+	s.NewUser("budi")
+
+	// Parallel guesses must not overwrite each other's count
+	var wg sync.WaitGroup
+	codes := make(chan int, 2*config.App.Lockout.Threshold)
+	for range cap(codes) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- s.JSON(http.MethodPost, "/api/login", "", map[string]string{"email": "budi@example.com", "password": "wrong"}).Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+	for code := range codes {
+		if code != http.StatusUnauthorized {
+			t.Fatalf("unexpected status %d", code)
+		}
+	}
+
+	s.JSON(http.MethodPost, "/api/login", "", map[string]string{"email": "budi@example.com", "password": "secret123"}).Expect(http.StatusUnauthorized)
+	sent.Await(t, 1)
+}
@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/config"
+	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/logging"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestRequestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(config.LogConfig{Format: "json", Level: "info"}, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logger)
+
+	s := NewServer(t)
+	userID, token := s.NewUser("budi")
+	lastLine := func() map[string]any {
+		t.Helper()
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		var line map[string]any
+		if err := json.Unmarshal([]byte(lines[len(lines)-1]), &line); err != nil {
+			t.Fatalf("not a JSON log line: %s", lines[len(lines)-1])
+		}
+		return line
+	}
+
+	// An ID is generated and logged with the route, status, user and error
+	w := s.Do(http.MethodDelete, "/api/blogs/999", nil, "", token).Expect(http.StatusNotFound)
+	id := w.Header().Get("X-Request-ID")
+	if !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(id) {
+		t.Fatalf("expected a generated request ID, got %q", id)
+	}
+	line := lastLine()
+	for key, want := range map[string]any{
+		"msg": "request", "level": "WARN", "request_id": id, "method": "DELETE", "route": "/api/blogs/:id",
+		"path": "/api/blogs/999", "status": float64(404), "user_id": float64(userID),
+		"bytes": float64(w.Body.Len()), "error": "Blog not found",
+	} {
+		if line[key] != want {
+			t.Errorf("%s: expected %v, got %v", key, want, line[key])
+		}
+	}
+	if _, ok := line["latency"]; !ok {
+		t.Errorf("missing latency in %v", line)
+	}
+
+	// The caller's ID is kept unless it is unusable
+	for header, keep := range map[string]bool{"trace-42": true, strings.Repeat("x", 200): false, "two words": false} {
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		req.Header.Set("X-Request-ID", header)
+		got := s.Serve(req).Expect(http.StatusOK).Header().Get("X-Request-ID")
+		if (got == header) != keep || got == "" {
+			t.Errorf("X-Request-ID %q: got %q", header, got)
+		}
+		if line := lastLine(); line["request_id"] != got || line["level"] != "INFO" || line["user_id"] != nil {
+			t.Errorf("unexpected log line %v", line)
+		}
+	}
+
+	// Failures log the cause, which the client never sees
+	s.Engine.GET("/panic", func(*gin.Context) { panic("boom") })
+	s.Get("/panic", "").Expect(http.StatusInternalServerError)
+	if line := lastLine(); line["level"] != "ERROR" || line["status"] != float64(500) || !strings.Contains(line["error"].(string), "panic: boom") {
+		t.Errorf("unexpected log line %v", line)
+	}
+	if !strings.Contains(buf.String(), `"msg":"panic recovered"`) {
+		t.Errorf("the panic was not logged:\n%s", buf.String())
+	}
+}
+
+func TestLogConfig(t *testing.T) {
+	if _, err := logging.New(config.LogConfig{Format: "xml", Level: "info"}, &bytes.Buffer{}); err == nil {
+		t.Fatal("expected an unknown format to be refused")
+	}
+
+	t.Setenv("SECRET", strings.Repeat("s", config.MinSecretLength))
+	t.Setenv("DNS", "blog.db")
+	t.Setenv("LOG_LEVEL", "loud")
+	if _, err := config.Load(filepath.Join(t.TempDir(), "missing.env")); err == nil || !strings.Contains(err.Error(), "LOG_LEVEL") {
+		t.Fatalf("expected a LOG_LEVEL validation error, got %v", err)
+	}
+}
+
+func TestGORMLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(config.LogConfig{Format: "json", Level: "info"}, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	DatabaseRefresh()
+	ctx := logging.NewContext(context.Background(), logger.With("request_id", "req-1"))
+	db := initializers.DB.Session(&gorm.Session{Logger: logging.NewGORMLogger(time.Nanosecond)}).WithContext(ctx)
+	lines := func() []map[string]any {
+		t.Helper()
+		var lines []map[string]any
+		for _, text := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var line map[string]any
+			if err := json.Unmarshal([]byte(text), &line); err != nil {
+				t.Fatalf("not a JSON log line: %s", text)
+			}
+			lines = append(lines, line)
+		}
+		buf.Reset()
+		return lines
+	}
+
+	// Slow queries are warnings with the request ID, without the bound values
+	db.Create(&models.User{Name: "Budi", Email: "budi@example.com"})
+	line := lines()[0]
+	for key, want := range map[string]any{"msg": "slow query", "level": "WARN", "request_id": "req-1", "rows": float64(1)} {
+		if line[key] != want {
+			t.Errorf("%s: expected %v, got %v", key, want, line[key])
+		}
+	}
+	if sql, _ := line["sql"].(string); !strings.Contains(sql, "INSERT INTO") || strings.Contains(sql, "budi@example.com") {
+		t.Errorf("unexpected sql %q", sql)
+	}
+	if line["duration"] == nil || line["threshold"] == nil || line["source"] == nil {
+		t.Errorf("missing attributes in %v", line)
+	}
+
+	// Failures are errors, missing records nothing
+	db.Exec("SELECT * FROM missing_table")
+	if line := lines()[0]; line["msg"] != "query failed" || line["level"] != "ERROR" || line["error"] == nil {
+		t.Errorf("unexpected log line %v", line)
+	}
+	db = initializers.DB.WithContext(ctx)
+	db.First(&models.User{}, 999)
+	if buf.Len() > 0 {
+		t.Errorf("a missing record was logged: %s", buf.String())
+	}
+
+	// Debug logs every query
+	db.Debug().First(&models.User{}, "email = ?", "budi@example.com")
+	if line := lines()[0]; line["msg"] != "query" || line["level"] != "INFO" || line["request_id"] != "req-1" {
+		t.Errorf("unexpected log line %v", line)
+	}
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	s := NewServer(t)
+
+	// The business counters are global, so compare with their value before
+	counters := map[string]func() float64{
+		"signups":       func() float64 { return testutil.ToFloat64(metrics.Signups) },
+		"logins ok":     func() float64 { return testutil.ToFloat64(metrics.Logins.WithLabelValues("succeeded")) },
+		"logins failed": func() float64 { return testutil.ToFloat64(metrics.Logins.WithLabelValues("failed")) },
+		"blogs":         func() float64 { return testutil.ToFloat64(metrics.BlogsPosted) },
+		"likes":         func() float64 { return testutil.ToFloat64(metrics.LikesToggled.WithLabelValues("liked")) },
+		"unlikes":       func() float64 { return testutil.ToFloat64(metrics.LikesToggled.WithLabelValues("unliked")) },
+		"comments":      func() float64 { return testutil.ToFloat64(metrics.CommentsPosted) },
+		"unknown routes": func() float64 {
+			return testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("GET", "unmatched", "404"))
+		},
+	}
+	before := make(map[string]float64)
+	for name, value := range counters {
+		before[name] = value()
+	}
+
+	_, token := s.NewUser("budi")
+	s.JSON(http.MethodPost, "/api/login", "", map[string]string{"email": "budi@example.com", "password": "wrong"}).Expect(http.StatusUnauthorized)
+	blogID := s.PostBlog(token, "Judul", "Content")
+	like := map[string]uint{"blog_id": blogID}
+	s.JSON(http.MethodPost, "/like", token, like).Expect(http.StatusCreated)
+	s.JSON(http.MethodPost, "/like", token, like).Expect(http.StatusOK)
+	s.JSON(http.MethodPost, "/like", token, like).Expect(http.StatusCreated)
+	s.JSON(http.MethodPost, "/comment", token, map[string]any{"blog_id": blogID, "comment": "Bagus sekali"}).Expect(http.StatusCreated)
+	s.Get("/does-not-exist", "").Expect(http.StatusNotFound)
+	s.Get(fmt.Sprintf("/api/blog/%d", blogID), token).Expect(http.StatusOK)
+
+	for name, want := range map[string]float64{
+		"signups": 1, "logins ok": 1, "logins failed": 1, "blogs": 1,
+		"likes": 2, "unlikes": 1, "comments": 1, "unknown routes": 1,
+	} {
+		if got := counters[name]() - before[name]; got != want {
+			t.Errorf("%s: expected +%v, got +%v", name, want, got)
+		}
+	}
+
+	// Everything is exposed in the Prometheus text format
+	w := s.Get("/metrics", "").Expect(http.StatusOK)
+	body := w.Body.String()
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/api/blog/:id",status="200"}`,
+		`http_request_duration_seconds_bucket{method="POST",route="/like",status="201",le="+Inf"}`,
+		`db_query_duration_seconds_count{operation="create",table="blogs"}`,
+		`db_query_duration_seconds_count{operation="query",table="users"}`,
+		"db_pool_open_connections ",
+		"db_pool_wait_count_total ",
+		"upload_size_bytes_count ",
+		`logins_total{result="failed"}`,
+		"go_goroutines ",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %s in:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "does-not-exist") {
+		t.Error("unknown paths must not become labels")
+	}
+}
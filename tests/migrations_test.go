@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/config"
+	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
+	"github.com/Tokenzrey/FPPBKKGOLANG/db/migrations"
+)
+
+func TestMigrations(t *testing.T) {
+	ctx := context.Background()
+	db, err := initializers.Connect(config.DatabaseConfig{Driver: "sqlite", DSN: filepath.Join(t.TempDir(), "blog.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+	migrator := migrations.New(db)
+	total := len(migrations.All())
+
+	done, err := migrator.Up(ctx, 0)
+	if err != nil || len(done) != total {
+		t.Fatalf("up: applied %d of %d: %v", len(done), total, err)
+	}
+	if !db.Migrator().HasTable("blogs") {
+		t.Fatal("blogs table missing after up")
+	}
+
+	// Running up again is a no-op
+	if done, err := migrator.Up(ctx, 0); err != nil || len(done) != 0 {
+		t.Fatalf("second up: applied %d: %v", len(done), err)
+	}
+
+	// Reverting everything leaves only the bookkeeping tables
+	if done, err := migrator.Down(ctx, 0); err != nil || len(done) != total {
+		t.Fatalf("down: reverted %d of %d: %v", len(done), total, err)
+	}
+	if db.Migrator().HasTable("users") {
+		t.Fatal("users table still present after down")
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.AppliedAt != nil {
+			t.Fatalf("migration %d still applied after down", s.Version)
+		}
+	}
+
+	// Another instance holding the lock blocks migrations
+	db.Exec("INSERT INTO schema_migrations_lock (id, owner, locked_at) VALUES (1, 'other', ?)", time.Now())
+	migrator.LockTimeout = 0
+	if _, err := migrator.Up(ctx, 0); !errors.Is(err, migrations.ErrLocked) {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+}
+
+func TestCreateMigration(t *testing.T) {
+	dir := t.TempDir()
+	path, err := migrations.Create(dir, "Add user avatars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(path, "_add_user_avatars.go") {
+		t.Fatalf("unexpected file name %s", path)
+	}
+	content, _ := os.ReadFile(path)
+	if !strings.Contains(string(content), `Name:    "add_user_avatars"`) {
+		t.Fatalf("unexpected template:\n%s", content)
+	}
+}
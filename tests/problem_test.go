@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/api/middleware"
+	format_errors "github.com/Tokenzrey/FPPBKKGOLANG/internal/format-errors"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/helpers"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestProblemDetails(t *testing.T) {
+	s := NewServer(t)
+	_, token := s.NewUser("budi")
+	s.Engine.GET("/limited", middleware.Handle(func(c *gin.Context) error {
+		return format_errors.RateLimited("Too many requests", 1500*time.Millisecond)
+	}))
+	s.Engine.GET("/missing", middleware.Handle(func(c *gin.Context) error {
+		return fmt.Errorf("loading the record: %w", gorm.ErrRecordNotFound)
+	}))
+
+	send := func(method, path, token, accept, body string) *Response {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return s.Serve(req)
+	}
+
+	for _, tc := range []struct {
+		name, method, path, token, body string
+		want                            format_errors.Problem
+	}{
+		{"handler", http.MethodGet, "/api/blog/999", token, "", format_errors.Problem{
+			Type: "/problems/not-found", Title: "Not Found", Status: 404, Detail: "Blog not found", Instance: "/api/blog/999", Code: helpers.CodeNotFound,
+		}},
+		{"middleware", http.MethodGet, "/api/users/", "", "", format_errors.Problem{
+			Type: "/problems/unauthorized", Title: "Unauthorized", Status: 401, Detail: "missing Authorization header", Instance: "/api/users/", Code: helpers.CodeUnauthorized,
+		}},
+		{"unknown route", http.MethodGet, "/nothing", "", "", format_errors.Problem{
+			Type: "/problems/route-not-found", Title: "Not Found", Status: 404, Detail: "Route not found", Instance: "/nothing", Code: helpers.CodeRouteNotFound,
+		}},
+		{"gorm error", http.MethodGet, "/missing", "", "", format_errors.Problem{
+			Type: "/problems/not-found", Title: "Not Found", Status: 404, Detail: "The record not found", Instance: "/missing", Code: helpers.CodeNotFound,
+		}},
+		{"rate limited", http.MethodGet, "/limited", "", "", format_errors.Problem{
+			Type: "/problems/rate-limited", Title: "Too Many Requests", Status: 429, Detail: "Too many requests", Instance: "/limited", Code: helpers.CodeRateLimited,
+		}},
+		{"validation", http.MethodPost, "/like", token, `{}`, format_errors.Problem{
+			Type: "/problems/validation-failed", Title: "Unprocessable Entity", Status: 422, Detail: "Validation failed", Instance: "/like", Code: helpers.CodeValidation,
+			Errors: map[string][]string{"blog_id": {"blog_id is a required field"}},
+		}},
+	} {
+		w := send(tc.method, tc.path, tc.token, "application/problem+json, application/json;q=0.9", tc.body).Expect(tc.want.Status)
+		if ct := w.Header().Get("Content-Type"); ct != format_errors.ProblemJSON {
+			t.Errorf("%s: expected %s, got %s", tc.name, format_errors.ProblemJSON, ct)
+		}
+		var got format_errors.Problem
+		w.Decode(&got)
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%s: expected %+v, got %+v", tc.name, tc.want, got)
+		}
+	}
+
+	// Retry-After is rounded up to whole seconds
+	if got := send(http.MethodGet, "/limited", "", "", "").Header().Get("Retry-After"); got != "2" {
+		t.Fatalf("expected Retry-After 2, got %q", got)
+	}
+
+	// Clients that do not ask for problem+json keep the envelope
+	for _, accept := range []string{"", "*/*", "application/json", "application/json, application/problem+json"} {
+		w := send(http.MethodGet, "/api/blog/999", token, accept, "").Expect(http.StatusNotFound)
+		var body helpers.APIResponse
+		w.Decode(&body)
+		if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") || body.Status != "error" || body.Code != helpers.CodeNotFound {
+			t.Errorf("Accept %q: unexpected response %s %s", accept, w.Header().Get("Content-Type"), w.Body)
+		}
+	}
+}
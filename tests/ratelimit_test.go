@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/api/router"
+	"github.com/Tokenzrey/FPPBKKGOLANG/config"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/helpers"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/ratelimit"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// failingStore is a store whose backend is down.
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+func TestRateLimit(t *testing.T) {
+	store := ratelimit.NewMemory()
+	ratelimit.Default = ratelimit.Groups{
+		Auth:  &ratelimit.Limiter{Name: "auth", Limit: ratelimit.Limit{Requests: 4, Period: time.Minute}, Store: store},
+		Write: &ratelimit.Limiter{Name: "write", Limit: ratelimit.Limit{Requests: 1, Period: time.Minute}, Store: store},
+	}
+	defer func() { ratelimit.Default = ratelimit.Groups{} }()
+
+	// Signing up and logging in take from the bucket of the client IP
+	s := NewServer(t)
+	_, budi := s.NewUser("budi")
+	_, siti := s.NewUser("siti")
+	login := map[string]string{"email": "budi@example.com", "password": "secret123"}
+	w := s.JSON(http.MethodPost, "/api/login", "", login).Expect(http.StatusTooManyRequests)
+	var body helpers.APIResponse
+	w.Decode(&body)
+	if body.Code != helpers.CodeRateLimited {
+		t.Fatalf("unexpected code %q", body.Code)
+	}
+	retry, _ := strconv.Atoi(w.Header().Get("Retry-After"))
+	if retry < 1 || retry > 15 || w.Header().Get("RateLimit-Limit") != "4" || w.Header().Get("RateLimit-Remaining") != "0" ||
+		w.Header().Get("RateLimit-Policy") != "4;w=60" || w.Header().Get("RateLimit-Reset") == "" {
+		t.Fatalf("unexpected rate limit headers: %v", w.Header())
+	}
+
+	// X-Forwarded-For is ignored unless it comes from a trusted proxy, so
+	// forging it does not give a fresh bucket
+	loginFrom := func(remoteAddr, forwardedFor string) *Response {
+		req := httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(`{"email": "budi@example.com", "password": "secret123"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		return s.Serve(req)
+	}
+	for _, spoofed := range []string{"203.0.113.1", "203.0.113.2", "203.0.113.3"} {
+		loginFrom("192.0.2.1:1234", spoofed).Expect(http.StatusTooManyRequests)
+	}
+
+	// Another client has its own bucket
+	if w := loginFrom("198.51.100.7:4321", "").Expect(http.StatusOK); w.Header().Get("RateLimit-Remaining") != "3" {
+		t.Fatalf("expected a fresh bucket for another IP, got %v", w.Header())
+	}
+
+	// Behind a trusted proxy, the clients it forwards are told apart
+	config.App.Server.TrustedProxies = "10.0.0.0/8"
+	s.Engine = gin.New()
+	router.GetRoute(s.Engine)
+	loginFrom("10.0.0.2:1234", "203.0.113.1").Expect(http.StatusOK)
+	if w := loginFrom("10.0.0.2:1234", "203.0.113.2").Expect(http.StatusOK); w.Header().Get("RateLimit-Remaining") != "3" {
+		t.Fatalf("expected a bucket per forwarded client, got %v", w.Header())
+	}
+
+	// Writes are limited per user, reads are not limited
+	blogID := s.PostBlog(budi, "Judul", "Content")
+	comment := map[string]any{"blog_id": blogID, "comment": "Bagus"}
+	s.JSON(http.MethodPost, "/comment", budi, comment).Expect(http.StatusTooManyRequests)
+	s.JSON(http.MethodPost, "/comment", siti, comment).Expect(http.StatusCreated)
+	s.JSON(http.MethodPost, "/like", siti, map[string]uint{"blog_id": blogID}).Expect(http.StatusTooManyRequests)
+	for range 3 {
+		s.Get("/api/blogs", "").Expect(http.StatusOK)
+	}
+
+	// Requests pass when the store is down
+	ratelimit.Default.Auth.Store = failingStore{}
+	s = NewServer(t)
+	s.Signup("andi", "andi@example.com", "secret123").Expect(http.StatusOK)
+}
+
+func TestRateLimitStores(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	limit := ratelimit.Limit{Requests: 2, Period: 200 * time.Millisecond}
+	for name, store := range map[string]ratelimit.Store{
+		"memory": ratelimit.NewMemory(),
+		"redis":  ratelimit.NewRedis(client, "ratelimit:"),
+	} {
+		ctx := context.Background()
+		take := func(key string) ratelimit.Result {
+			t.Helper()
+			res, err := store.Take(ctx, key, limit)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			return res
+		}
+
+		// A burst of the whole limit, then refusals until a token is back
+		if first, second := take("a"), take("a"); !first.Allowed || first.Remaining != 1 || !second.Allowed || second.Remaining != 0 {
+			t.Fatalf("%s: unexpected burst %+v %+v", name, first, second)
+		}
+		refused := take("a")
+		if refused.Allowed || refused.RetryAfter <= 0 || refused.RetryAfter > 100*time.Millisecond || refused.Reset > limit.Period {
+			t.Fatalf("%s: expected a refusal, got %+v", name, refused)
+		}
+		if other := take("b"); !other.Allowed {
+			t.Fatalf("%s: keys share a bucket", name)
+		}
+		time.Sleep(refused.RetryAfter + 10*time.Millisecond)
+		if again := take("a"); !again.Allowed {
+			t.Fatalf("%s: expected a refilled token, got %+v", name, again)
+		}
+	}
+
+	// The buckets expire from Redis once full again
+	if ttl := server.TTL("ratelimit:a"); ttl <= 0 || ttl > limit.Period {
+		t.Fatalf("unexpected TTL %v", ttl)
+	}
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/config"
+	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
+	format_errors "github.com/Tokenzrey/FPPBKKGOLANG/internal/format-errors"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/helpers"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/repository"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestDeleteBlogCascades(t *testing.T) {
+	ctx := context.Background()
+	DatabaseRefresh()
+
+	for name, repos := range map[string]repository.Repositories{"gorm": repository.NewGORM(initializers.DB), "memory": repository.NewMemory()} {
+		t.Run(name, func(t *testing.T) {
+			author := models.User{Name: "Author", Email: name + "@example.com"}
+			if err := repos.Users.Create(ctx, &author); err != nil {
+				t.Fatal(err)
+			}
+			blog := models.Blog{Judul: "Judul", Content: "Content", Thumbnail: name + ".png", UserID: author.ID}
+			if err := repos.Blogs.Create(ctx, &blog); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := repos.Likes.Toggle(ctx, author.ID, blog.ID); err != nil {
+				t.Fatal(err)
+			}
+			comment := models.Comment{Comment: "Bagus", UserID: author.ID, BlogID: blog.ID}
+			if err := repos.Comments.Create(ctx, &comment); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := repos.Blogs.Delete(ctx, blog); err != nil {
+				t.Fatal(err)
+			}
+			if liked, _ := repos.Likes.Exists(ctx, author.ID, blog.ID); liked {
+				t.Fatal("the like outlived its blog")
+			}
+			if comments, _ := repos.Comments.ListByBlog(ctx, blog.ID); len(comments) != 0 {
+				t.Fatalf("%d comments outlived their blog", len(comments))
+			}
+			if count, _ := repos.Blogs.CountByThumbnail(ctx, blog.Thumbnail); count != 0 {
+				t.Fatalf("the deleted blog still counts as using its thumbnail")
+			}
+		})
+	}
+
+	// Not even soft-deleted rows remain in the database
+	for _, model := range []any{&models.Blog{}, &models.Like{}, &models.Comment{}} {
+		var count int64
+		initializers.DB.Unscoped().Model(model).Count(&count)
+		if count != 0 {
+			t.Errorf("%T: %d rows remain", model, count)
+		}
+	}
+}
+
+func TestToggleRetriesDeadlocks(t *testing.T) {
+	ctx := context.Background()
+	DatabaseRefresh()
+	author := models.User{Name: "Author", Email: "author@example.com"}
+	initializers.DB.Create(&author)
+	blog := models.Blog{Judul: "Judul", Content: "Content", UserID: author.ID}
+	initializers.DB.Create(&blog)
+
+	// A connection of its own whose inserts of likes lose deadlocks, as
+	// under MySQL when concurrent first likes wait on each other's gap locks
+	dialector, err := initializers.Dialector(config.App.Database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	deadlocks := 0
+	db.Callback().Create().Before("gorm:create").Register("test:deadlock", func(tx *gorm.DB) {
+		if tx.Statement.Table == "likes" && deadlocks > 0 {
+			deadlocks--
+			tx.AddError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock; try restarting transaction"})
+		}
+	})
+	likes := repository.NewGORM(db).Likes
+
+	// A lost deadlock is run again
+	deadlocks = 1
+	if liked, err := likes.Toggle(ctx, author.ID, blog.ID); err != nil || !liked {
+		t.Fatalf("expected the retry to like the blog, got %v %v", liked, err)
+	}
+	initializers.DB.First(&blog, blog.ID)
+	if blog.LikeCount != 1 {
+		t.Fatalf("expected the like to be counted once, got %d", blog.LikeCount)
+	}
+
+	// Losing every attempt reports a conflict and changes nothing
+	likes.Toggle(ctx, author.ID, blog.ID)
+	deadlocks = 100
+	_, err = likes.Toggle(ctx, author.ID, blog.ID)
+	if !errors.Is(err, repository.ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	if e := format_errors.From(err); e.Status != http.StatusConflict || e.Code != helpers.CodeConflict {
+		t.Fatalf("expected a 409, got %d %s", e.Status, e.Code)
+	}
+	initializers.DB.First(&blog, blog.ID)
+	if liked, _ := likes.Exists(ctx, author.ID, blog.ID); liked || blog.LikeCount != 0 {
+		t.Fatalf("the aborted like was kept: liked %v, counter %d", liked, blog.LikeCount)
+	}
+}
@@ -0,0 +1,49 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/helpers"
+	"github.com/gin-gonic/gin"
+)
+
+func TestResponseEnvelope(t *testing.T) {
+	s := NewServer(t)
+	_, token := s.NewUser("budi")
+	s.Engine.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	for _, tc := range []struct {
+		name string
+		w    *Response
+		code int
+		want helpers.ErrorCode
+	}{
+		{"unknown route", s.Get("/api/nothing", ""), http.StatusNotFound, helpers.CodeRouteNotFound},
+		{"middleware abort", s.Get("/api/users/", ""), http.StatusUnauthorized, helpers.CodeUnauthorized},
+		{"panic", s.Get("/panic", ""), http.StatusInternalServerError, helpers.CodeInternal},
+		{"handler error", s.Get("/api/blogs?page=0", ""), http.StatusBadRequest, helpers.CodeBadRequest},
+		{"validation", s.JSON(http.MethodPost, "/like", token, `{}`), http.StatusUnprocessableEntity, helpers.CodeValidation},
+		{"specific code", s.JSON(http.MethodPost, "/api/login", "", map[string]string{"email": "budi@example.com", "password": "wrong"}), http.StatusUnauthorized, helpers.CodeInvalidCredentials},
+	} {
+		var body helpers.APIResponse
+		tc.w.Decode(&body)
+		if tc.w.Code != tc.code || body.Status != "error" || body.Code != tc.want || body.Message == "" || body.Data != nil {
+			t.Errorf("%s: unexpected %d response %s", tc.name, tc.w.Code, tc.w.Body)
+		}
+	}
+
+	// Successful responses use the same envelope without a code; lists carry meta
+	var body helpers.APIResponse
+	s.JSON(http.MethodPost, "/like", token, map[string]uint{"blog_id": s.PostBlog(token, "Judul", "Content")}).
+		Expect(http.StatusCreated).Decode(&body)
+	if body.Status != "success" || body.Code != "" || body.Data == nil || body.Meta != nil {
+		t.Fatalf("unexpected like response: %+v", body)
+	}
+
+	var page helpers.Envelope[[]any]
+	s.Get("/api/blogs?page=2", "").Expect(http.StatusOK).Decode(&page)
+	if page.Data == nil || len(page.Data) != 0 || page.Meta == nil || page.Meta.Total != 1 || page.Meta.CurrentPage != 2 {
+		t.Fatalf("unexpected empty page: %+v", page)
+	}
+}
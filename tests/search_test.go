@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/api/router"
+	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/search"
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseQuery(t *testing.T) {
+	q := search.ParseQuery(`Golang "gin framework" tutor* <script>`)
+
+	expected := []search.Term{
+		{Text: "golang"},
+		{Text: "gin framework", Phrase: true},
+		{Text: "tutor", Prefix: true},
+		{Text: "script"},
+	}
+	if len(q.Terms) != len(expected) {
+		t.Fatalf("expected %d terms, got %+v", len(expected), q.Terms)
+	}
+	for i, term := range expected {
+		if q.Terms[i] != term {
+			t.Fatalf("term %d: expected %+v, got %+v", i, term, q.Terms[i])
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	q := search.ParseQuery(`"belajar golang" tutor*`)
+
+	got := search.Highlight("Belajar Golang & <b>Tutorial</b> Gin", q, 0)
+	want := "<mark>Belajar Golang</mark> &amp; &lt;b&gt;<mark>Tutorial</mark>&lt;/b&gt; Gin"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	// Long text is cut to a window around the first match
+	long := "lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod tempor " +
+		"incididunt ut labore et dolore magna aliqua tutorial ut enim ad minim veniam quis nostrud"
+	snippet := search.Highlight(long, q, 40)
+	want = "…magna aliqua <mark>tutorial</mark> ut enim ad minim veniam…"
+	if snippet != want {
+		t.Fatalf("expected %q, got %q", want, snippet)
+	}
+}
+
+func TestMemorySearch(t *testing.T) {
+	index := search.NewMemory()
+	blogs := []models.Blog{
+		{Judul: "Belajar Golang", Content: "Tutorial dasar untuk pemula", User: models.User{Name: "Budi"}},
+		{Judul: "Resep makanan", Content: "Anak-anak bermain sambil belajar golang di taman", User: models.User{Name: "Siti"}},
+		{Judul: "Running a Gin server", Content: "Programming web services in Go", User: models.User{Name: "Golang Fan"}},
+	}
+	for i := range blogs {
+		blogs[i].ID = uint(i + 1)
+		blogs[i].CreatedAt = time.Date(2024, 1, i+1, 0, 0, 0, 0, time.UTC)
+		index.Index(blogs[i])
+	}
+
+	ids := func(query, filter string) []uint {
+		result, err := index.Search(context.Background(), search.Request{Query: query, Filter: filter, Page: 1, PerPage: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var found []uint
+		for _, hit := range result.Data.([]search.Hit) {
+			found = append(found, hit.ID)
+		}
+		return found
+	}
+
+	cases := []struct {
+		query, filter string
+		want          []uint
+	}{
+		{"golang", "all", []uint{1, 3, 2}},         // Title match is boosted, short fields rank higher
+		{"golang", "content", []uint{2}},           // Filters restrict the searched fields
+		{"main", "all", []uint{2}},                 // Indonesian stemming: bermain -> main
+		{"run programs", "all", []uint{3}},         // English stemming: running -> run, programming -> program
+		{`"belajar golang"`, "content", []uint{2}}, // Phrases must be consecutive
+		{"tutor*", "all", []uint{1}},               // Prefix queries
+		{"yang dan", "all", nil},                   // Only stopwords
+	}
+	for _, tc := range cases {
+		if got := ids(tc.query, tc.filter); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("search %q (%s): expected %v, got %v", tc.query, tc.filter, tc.want, got)
+		}
+	}
+
+	// Removed blogs disappear from results
+	index.Remove(1)
+	if got := ids("golang", "judul"); len(got) != 0 {
+		t.Fatalf("expected no results after removal, got %v", got)
+	}
+}
+
+func TestSearchEndpoint(t *testing.T) {
+	s := NewServer(t)
+	_, budi := s.NewUser("budi")
+	_, siti := s.NewUser("siti")
+	golang := s.PostBlog(budi, "Belajar Golang", "Tutorial dasar untuk pemula")
+	s.PostBlog(siti, "Resep Makanan", "Belajar memasak golang-golang di dapur")
+	s.PostBlog(siti, "Catatan Harian", "Tidak ada yang istimewa")
+
+	type page struct {
+		Data []struct {
+			ID         uint              `json:"ID"`
+			Highlights map[string]string `json:"highlights"`
+		} `json:"data"`
+		Meta struct {
+			Total int64 `json:"total"`
+		} `json:"meta"`
+	}
+	find := func(query string) page {
+		t.Helper()
+		var p page
+		s.Get("/api/blogs/search?"+query, "").Expect(http.StatusOK).Decode(&p)
+		return p
+	}
+
+	// Title matches rank first and are highlighted
+	all := find("search=golang")
+	if all.Meta.Total != 2 || all.Data[0].ID != golang || !strings.Contains(all.Data[0].Highlights["judul"], "<mark>Golang</mark>") {
+		t.Fatalf("unexpected results: %+v", all)
+	}
+	if byAuthor := find("search=siti&filter=username"); byAuthor.Meta.Total != 2 {
+		t.Fatalf("expected both blogs of siti, got %+v", byAuthor)
+	}
+	if paged := find("search=golang&perPage=1&page=2"); paged.Meta.Total != 2 || len(paged.Data) != 1 || paged.Data[0].ID == golang {
+		t.Fatalf("unexpected second page: %+v", paged)
+	}
+	if none := find("search=kubernetes"); none.Meta.Total != 0 || len(none.Data) != 0 {
+		t.Fatalf("expected no results, got %+v", none)
+	}
+
+	for _, query := range []string{"search=golang&filter=title", "search=golang&page=0", "search=golang&perPage=101"} {
+		s.Get("/api/blogs/search?"+query, "").Expect(http.StatusBadRequest)
+	}
+
+	// Suggestions come from the index filled while publishing
+	var suggestions struct {
+		Titles []struct {
+			Text string `json:"text"`
+		} `json:"titles"`
+	}
+	s.Get("/api/blogs/suggest?q=bel", "").Expect(http.StatusOK).Data(&suggestions)
+	if len(suggestions.Titles) != 1 || suggestions.Titles[0].Text != "Belajar Golang" {
+		t.Fatalf("unexpected suggestions: %+v", suggestions)
+	}
+	s.Get("/api/blogs/suggest?q=bel&limit=0", "").Expect(http.StatusBadRequest)
+}
+
+func TestMemorySearchCounters(t *testing.T) {
+	s := NewServer(t)
+	_, budi := s.NewUser("budi")
+	blogID := s.PostBlog(budi, "Belajar Golang", "Tutorial dasar untuk pemula")
+
+	// The index is built before the blog is liked and commented
+	index := search.NewMemory()
+	if err := index.Load(initializers.DB); err != nil {
+		t.Fatal(err)
+	}
+	search.Default = index
+	s.Engine = gin.New()
+	router.GetRoute(s.Engine)
+	s.JSON(http.MethodPost, "/like", budi, map[string]uint{"blog_id": blogID}).Expect(http.StatusCreated)
+	s.JSON(http.MethodPost, "/comment", budi, map[string]any{"blog_id": blogID, "comment": "Tulisan yang bagus"}).Expect(http.StatusCreated)
+
+	var found struct {
+		Data []struct {
+			LikeCount    int64 `json:"like_count"`
+			CommentCount int64 `json:"comment_count"`
+		} `json:"data"`
+	}
+	s.Get("/api/blogs/search?search=golang", "").Expect(http.StatusOK).Decode(&found)
+	if len(found.Data) != 1 || found.Data[0].LikeCount != 1 || found.Data[0].CommentCount != 1 {
+		t.Fatalf("expected the current counters, got %+v", found.Data)
+	}
+}
@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
+	"github.com/Tokenzrey/FPPBKKGOLANG/db/seeds"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/storage"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSeed(t *testing.T) {
+	DatabaseRefresh()
+	local, err := storage.NewLocal(t.TempDir(), "/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := seeds.Options{Users: 3, Blogs: 10, Likes: 100, Comments: 15, Thumbnails: 2, Seed: 7, Password: "secret123"}
+	summary, err := seeds.Run(context.Background(), initializers.DB, local, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only 3*10 distinct likes exist
+	if summary.Users != 3 || summary.Blogs != 10 || summary.Likes != 30 || summary.Comments != 15 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+
+	var user models.User
+	initializers.DB.Where("email = ?", seeds.Email(2)).First(&user)
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("secret123")) != nil {
+		t.Fatal("seeded user does not accept the known password")
+	}
+
+	var blog models.Blog
+	initializers.DB.First(&blog)
+	if file, err := local.Get(context.Background(), blog.Thumbnail); err != nil {
+		t.Fatalf("thumbnail %s not stored: %v", blog.Thumbnail, err)
+	} else {
+		file.Close()
+	}
+
+	// Seeding again reuses the users
+	summary, err = seeds.Run(context.Background(), initializers.DB, local, opts)
+	if err != nil || summary.Users != 0 || summary.Blogs != 10 {
+		t.Fatalf("second run: %+v, %v", summary, err)
+	}
+}
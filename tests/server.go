@@ -0,0 +1,200 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/api/router"
+	"github.com/Tokenzrey/FPPBKKGOLANG/db/initializers"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/search"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/storage"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/suggest"
+	"github.com/gin-gonic/gin"
+)
+
+// Server is the application under test: the routes of router.GetRoute on a
+// freshly migrated test database, with uploads in a temporary directory and
+// empty search and suggestion indexes. It needs no .env or database server.
+type Server struct {
+	t         *testing.T
+	Engine    *gin.Engine
+	UploadDir string
+}
+
+// NewServer resets the test database and the application's backends and
+// builds the engine.
+func NewServer(t *testing.T) *Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	DatabaseRefresh()
+
+	dir := t.TempDir()
+	local, err := storage.NewLocal(dir, "/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.Default = storage.Traced(local)
+	search.Default = search.NewSQL(initializers.DB)
+	suggest.Default = suggest.NewIndex()
+
+	r := gin.New()
+	router.GetRoute(r)
+	return &Server{t: t, Engine: r, UploadDir: dir}
+}
+
+// Response is a recorded response.
+type Response struct {
+	*httptest.ResponseRecorder
+	t *testing.T
+}
+
+// Decode unmarshals the body into v.
+func (r *Response) Decode(v any) {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Body.Bytes(), v); err != nil {
+		r.t.Fatalf("decode %s: %v", r.Body, err)
+	}
+}
+
+// Data unmarshals the data field of an API response into v.
+func (r *Response) Data(v any) {
+	r.t.Helper()
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	r.Decode(&envelope)
+	if err := json.Unmarshal(envelope.Data, v); err != nil {
+		r.t.Fatalf("decode data of %s: %v", r.Body, err)
+	}
+}
+
+// Expect fails the test unless the response has the status code.
+func (r *Response) Expect(code int) *Response {
+	r.t.Helper()
+	if r.Code != code {
+		r.t.Fatalf("expected status %d, got %d: %s", code, r.Code, r.Body)
+	}
+	return r
+}
+
+// Do sends a request, authenticated when token is not empty.
+func (s *Server) Do(method, path string, body io.Reader, contentType, token string) *Response {
+	req := httptest.NewRequest(method, path, body)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return s.Serve(req)
+}
+
+// Serve sends a prepared request, e.g. one with extra headers.
+func (s *Server) Serve(req *http.Request) *Response {
+	w := httptest.NewRecorder()
+	s.Engine.ServeHTTP(w, req)
+	return &Response{ResponseRecorder: w, t: s.t}
+}
+
+// JSON sends body encoded as JSON; a string is sent as it is.
+func (s *Server) JSON(method, path, token string, body any) *Response {
+	s.t.Helper()
+	raw, ok := body.(string)
+	if !ok {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		raw = string(encoded)
+	}
+	return s.Do(method, path, bytes.NewBufferString(raw), "application/json", token)
+}
+
+// Get sends a GET request.
+func (s *Server) Get(path, token string) *Response {
+	return s.Do(http.MethodGet, path, nil, "", token)
+}
+
+// Upload sends a multipart form with the fields and, unless fileName is
+// empty, a file in the thumbnail field.
+func (s *Server) Upload(path, token string, fields map[string]string, fileName string, content []byte) *Response {
+	s.t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		form.WriteField(name, value)
+	}
+	if fileName != "" {
+		file, err := form.CreateFormFile("thumbnail", fileName)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		file.Write(content)
+	}
+	form.Close()
+
+	return s.Do(http.MethodPost, path, &body, form.FormDataContentType(), token)
+}
+
+// Signup registers a user with valid profile fields.
+func (s *Server) Signup(name, email, password string) *Response {
+	return s.JSON(http.MethodPost, "/api/signup", "", map[string]string{
+		"name":          name,
+		"email":         email,
+		"password":      password,
+		"tanggal_lahir": "2000-01-02",
+		"biografi":      "Halo, saya " + name,
+	})
+}
+
+// Login returns a token for the credentials, failing the test if they are refused.
+func (s *Server) Login(email, password string) string {
+	s.t.Helper()
+	var data struct {
+		Token string `json:"token"`
+	}
+	s.JSON(http.MethodPost, "/api/login", "", map[string]string{"email": email, "password": password}).
+		Expect(http.StatusOK).Data(&data)
+	return data.Token
+}
+
+// NewUser signs up a user named name and returns their ID and a token.
+func (s *Server) NewUser(name string) (uint, string) {
+	s.t.Helper()
+	email := fmt.Sprintf("%s@example.com", name)
+	var user struct {
+		ID uint `json:"id"`
+	}
+	s.Signup(name, email, "secret123").Expect(http.StatusOK).Data(&user)
+	return user.ID, s.Login(email, "secret123")
+}
+
+// encodePNG returns a blank PNG image of the given size.
+func encodePNG(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// PostBlog publishes a blog with a generated PNG thumbnail and returns its ID.
+func (s *Server) PostBlog(token, judul, content string) uint {
+	s.t.Helper()
+	var data struct {
+		Blog struct {
+			ID uint `json:"id"`
+		} `json:"blog"`
+	}
+	s.Upload("/api/blogs/", token, map[string]string{"judul": judul, "content": content}, "thumbnail.png", encodePNG(s.t, 64, 48)).
+		Expect(http.StatusOK).Data(&data)
+	return data.Blog.ID
+}
@@ -0,0 +1,252 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/api/router"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/imaging"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/repository"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/service"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/storage"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/suggest"
+	"github.com/gin-gonic/gin"
+)
+
+// memoryServices returns services on in-memory repositories and a temporary upload directory.
+func memoryServices(t *testing.T) service.Services {
+	local, err := storage.NewLocal(t.TempDir(), "/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service.New(repository.NewMemory(), service.Options{
+		Storage:     local,
+		Suggestions: suggest.NewIndex(),
+		Secret:      strings.Repeat("t", 32),
+	})
+}
+
+func TestServices(t *testing.T) {
+	ctx := context.Background()
+	services := memoryServices(t)
+
+	// Signup refuses a taken email, login checks the password
+	author, err := services.Users.Signup(ctx, service.Profile{Name: "Author", Email: "author@example.com"}, "secret1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := services.Users.Signup(ctx, service.Profile{Name: "Copy", Email: "author@example.com"}, "secret2"); !errors.Is(err, service.ErrEmailTaken) {
+		t.Fatalf("expected ErrEmailTaken, got %v", err)
+	}
+	if token, err := services.Users.Login(ctx, "author@example.com", "secret1", "192.0.2.1"); err != nil || token == "" {
+		t.Fatalf("login: %q %v", token, err)
+	}
+	if _, err := services.Users.Login(ctx, "author@example.com", "wrong", "192.0.2.1"); !errors.Is(err, service.ErrInvalidCredentials) {
+		t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+	}
+	reader, _ := services.Users.Signup(ctx, service.Profile{Name: "Reader", Email: "reader@example.com"}, "secret3")
+
+	// Publishing processes the thumbnail; a broken one is refused
+	if _, err := services.Blogs.Create(ctx, author.ID, "Judul", "Content", []byte("not an image")); !errors.Is(err, imaging.ErrUnsupportedFormat) {
+		t.Fatalf("expected ErrUnsupportedFormat, got %v", err)
+	}
+	blog, err := services.Blogs.Create(ctx, author.ID, "Belajar Golang", "Content", encodePNG(t, 40, 30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blog.User.Name != "Author" || !strings.HasSuffix(blog.Thumbnail, ".png") {
+		t.Fatalf("unexpected blog: %+v", blog)
+	}
+	if result := services.Blogs.Suggest("belajar", 5); len(result.Titles) != 1 {
+		t.Fatalf("expected the blog to be suggested, got %+v", result)
+	}
+
+	// Every service reports a missing blog the same way
+	if _, err := services.Likes.Toggle(ctx, reader.ID, 999); !errors.Is(err, service.ErrBlogNotFound) {
+		t.Fatalf("like: expected ErrBlogNotFound, got %v", err)
+	}
+	if _, err := services.Comments.Post(ctx, reader.ID, 999, "Nice post"); !errors.Is(err, service.ErrBlogNotFound) {
+		t.Fatalf("comment: expected ErrBlogNotFound, got %v", err)
+	}
+	if _, err := services.Blogs.Get(ctx, 999, reader.ID); !errors.Is(err, service.ErrBlogNotFound) {
+		t.Fatalf("get: expected ErrBlogNotFound, got %v", err)
+	}
+
+	// Likes and comments update the counters
+	if liked, err := services.Likes.Toggle(ctx, reader.ID, blog.ID); !liked || err != nil {
+		t.Fatalf("like: %v %v", liked, err)
+	}
+	comment, err := services.Comments.Post(ctx, reader.ID, blog.ID, "Nice post")
+	if err != nil {
+		t.Fatal(err)
+	}
+	detail, err := services.Blogs.Get(ctx, blog.ID, reader.ID)
+	if err != nil || !detail.Liked || detail.Blog.LikeCount != 1 || detail.Blog.CommentCount != 1 || len(detail.Comments) != 1 {
+		t.Fatalf("unexpected detail: %+v %v", detail, err)
+	}
+	if detail.Comments[0].User.Name != "Reader" {
+		t.Fatalf("expected the comment author to be loaded, got %+v", detail.Comments[0].User)
+	}
+
+	// Only authors delete their comments and blogs
+	if _, err := services.Comments.Delete(ctx, author.ID, comment.ID); !errors.Is(err, service.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if _, err := services.Comments.Delete(ctx, reader.ID, comment.ID); err != nil {
+		t.Fatal(err)
+	}
+	if liked, _ := services.Likes.Toggle(ctx, reader.ID, blog.ID); liked {
+		t.Fatal("expected the second toggle to unlike")
+	}
+	if _, blogLiked, _ := services.Likes.Status(ctx, reader.ID, blog.ID); blogLiked {
+		t.Fatal("expected the blog not to be liked")
+	}
+	if _, err := services.Blogs.Delete(ctx, reader.ID, blog.ID); !errors.Is(err, service.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if _, err := services.Blogs.Delete(ctx, author.ID, blog.ID); err != nil {
+		t.Fatal(err)
+	}
+	if result := services.Blogs.Suggest("belajar", 5); len(result.Titles) != 0 {
+		t.Fatalf("expected the deleted blog to leave the suggestions, got %+v", result)
+	}
+
+	// Renaming the author is refused when the new email is taken
+	if _, err := services.Users.Update(ctx, author.ID, service.Profile{Name: "Author", Email: "reader@example.com"}); !errors.Is(err, service.ErrEmailTaken) {
+		t.Fatalf("expected ErrEmailTaken, got %v", err)
+	}
+}
+
+func TestRegisterWithMemoryRepositories(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// Tokens are signed and verified with the secret of the services, not
+	// the configured one
+	dir := t.TempDir()
+	local, err := storage.NewLocal(dir, "/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	services := service.New(repository.NewMemory(), service.Options{Storage: local, Secret: strings.Repeat("m", 32)})
+
+	r := gin.New()
+	router.Register(r, services, router.Dependencies{Storage: local})
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	signup := `{"name": "Budi", "email": "budi@example.com", "password": "secret1", "tanggal_lahir": "2000-01-02", "biografi": "Halo"}`
+	if w := post("/api/signup", signup); w.Code != http.StatusOK {
+		t.Fatalf("signup: got %d %s", w.Code, w.Body)
+	}
+	if w := post("/api/signup", signup); w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "email is already registered") {
+		t.Fatalf("second signup: got %d %s", w.Code, w.Body)
+	}
+	w := post("/api/login", `{"email": "budi@example.com", "password": "secret1"}`)
+	var login struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	if json.Unmarshal(w.Body.Bytes(), &login); w.Code != http.StatusOK || login.Data.Token == "" {
+		t.Fatalf("login: got %d %s", w.Code, w.Body)
+	}
+	get := func(path, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := get("/api/users/", login.Data.Token); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "budi@example.com") {
+		t.Fatalf("profile: got %d %s", w.Code, w.Body)
+	}
+
+	// Uploads and probes use the storage given, and there is no database
+	os.WriteFile(filepath.Join(dir, "a.png"), []byte("image"), 0o644)
+	if w := get("/uploads/a.png", ""); w.Code != http.StatusOK || w.Body.String() != "image" {
+		t.Fatalf("upload: got %d %s", w.Code, w.Body)
+	}
+	if w := get("/readyz", ""); w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), `"storage":{"status":"up"`) || !strings.Contains(w.Body.String(), `"database":{"status":"down"`) {
+		t.Fatalf("readyz: got %d %s", w.Code, w.Body)
+	}
+}
+
+// pausedBlogs holds the saving of blogs until released.
+type pausedBlogs struct {
+	repository.BlogRepository
+	saving  chan struct{}
+	release chan struct{}
+}
+
+func (r pausedBlogs) Create(ctx context.Context, blog *models.Blog) error {
+	r.saving <- struct{}{}
+	<-r.release
+	return r.BlogRepository.Create(ctx, blog)
+}
+
+func TestThumbnailDeleteRacingCreate(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	local, err := storage.NewLocal(dir, "/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos := repository.NewMemory()
+	services := service.New(repos, service.Options{Storage: local, Secret: strings.Repeat("t", 32)})
+	author, err := services.Users.Signup(ctx, service.Profile{Name: "Author", Email: "author@example.com"}, "secret1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumbnail := encodePNG(t, 40, 30)
+	first, err := services.Blogs.Create(ctx, author.ID, "Pertama", "Content", thumbnail)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The identical upload has written its files but not saved its blog
+	// when the last blog using them is deleted
+	paused := pausedBlogs{BlogRepository: repos.Blogs, saving: make(chan struct{}), release: make(chan struct{})}
+	repos.Blogs = paused
+	racing := service.New(repos, service.Options{Storage: local, Secret: strings.Repeat("t", 32)})
+	created := make(chan error)
+	go func() {
+		_, err := racing.Blogs.Create(ctx, author.ID, "Kedua", "Content", thumbnail)
+		created <- err
+	}()
+	<-paused.saving
+	deleted := make(chan error)
+	go func() {
+		_, err := racing.Blogs.Delete(ctx, author.ID, first.ID)
+		deleted <- err
+	}()
+	select {
+	case err := <-deleted:
+		t.Fatalf("the delete did not wait for the upload to be saved: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(paused.release)
+	if err := <-created; err != nil {
+		t.Fatal(err)
+	}
+	if err := <-deleted; err != nil {
+		t.Fatal(err)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 1+len(imaging.Widths) {
+		t.Fatalf("expected the files of the new blog to be kept, got %d files", len(entries))
+	}
+}
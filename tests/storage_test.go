@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/storage"
+)
+
+// fakeS3 is a minimal in-memory stand-in for an S3-compatible server.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = body
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestStorageBackends(t *testing.T) {
+	uploads := t.TempDir()
+	local, err := storage.NewLocal(uploads, "/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(&fakeS3{objects: map[string][]byte{}})
+	defer server.Close()
+
+	s3, err := storage.NewS3(storage.S3Config{
+		Endpoint:  server.URL,
+		Bucket:    "thumbnails",
+		AccessKey: "key",
+		SecretKey: "secret",
+		PublicURL: "https://cdn.example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backends := map[string]storage.Storage{"local": local, "s3": s3}
+	for name, backend := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if err := backend.Ping(ctx); err != nil {
+				t.Fatalf("ping: %v", err)
+			}
+			if err := backend.Put(ctx, "a.png", strings.NewReader("image"), "image/png"); err != nil {
+				t.Fatalf("put: %v", err)
+			}
+
+			file, err := backend.Get(ctx, "a.png")
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			content, _ := io.ReadAll(file)
+			file.Close()
+			if string(content) != "image" {
+				t.Fatalf("expected content %q, got %q", "image", content)
+			}
+
+			if err := backend.Delete(ctx, "a.png"); err != nil {
+				t.Fatalf("delete: %v", err)
+			}
+			if _, err := backend.Get(ctx, "a.png"); !errors.Is(err, storage.ErrNotFound) {
+				t.Fatalf("expected ErrNotFound after delete, got %v", err)
+			}
+		})
+	}
+	if left, _ := os.ReadDir(uploads); len(left) > 0 {
+		t.Fatalf("ping left %s behind", left[0].Name())
+	}
+
+	// Ping fails when the directory is gone or cannot be written, or the
+	// credentials are refused
+	dir := filepath.Join(t.TempDir(), "uploads")
+	gone, _ := storage.NewLocal(dir, "/uploads")
+	os.Remove(dir)
+	refused, _ := storage.NewS3(storage.S3Config{Endpoint: server.URL, Bucket: "thumbnails", AccessKey: "other", SecretKey: "secret"})
+	failing := map[string]storage.Storage{"local gone": gone, "s3": refused}
+	if os.Geteuid() != 0 { // Permissions do not stop root
+		readOnly := t.TempDir()
+		failing["local read-only"], _ = storage.NewLocal(readOnly, "/uploads")
+		os.Chmod(readOnly, 0o555)
+		defer os.Chmod(readOnly, 0o755)
+	}
+	for name, backend := range failing {
+		if err := backend.Ping(context.Background()); err == nil {
+			t.Errorf("%s: expected ping to fail", name)
+		}
+	}
+
+	if url := s3.URL("a.png"); url != "https://cdn.example.com/a.png" {
+		t.Fatalf("unexpected s3 url %q", url)
+	}
+	if url := local.URL("a.png"); url != "/uploads/a.png" {
+		t.Fatalf("unexpected local url %q", url)
+	}
+}
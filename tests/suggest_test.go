@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/models"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/suggest"
+)
+
+func TestSuggest(t *testing.T) {
+	index := suggest.NewIndex()
+
+	blog := func(id, userID uint, title, author string) models.Blog {
+		b := models.Blog{Judul: title, UserID: userID, User: models.User{Name: author}}
+		b.ID = id
+		return b
+	}
+	index.Add(blog(1, 1, "Belajar Golang", "Budi"), 1)
+	index.Add(blog(2, 2, "Golang untuk Pemula", "Siti"), 10)
+	index.Add(blog(3, 2, "Resep Gado-gado", "Siti"), 0)
+
+	titles := func(query string) []uint {
+		var ids []uint
+		for _, s := range index.Suggest(query, 5).Titles {
+			ids = append(ids, s.ID)
+		}
+		return ids
+	}
+
+	// Titles starting with the query rank above later words; likes break ties
+	if got := titles("gol"); len(got) != 2 || got[0] != 2 || got[1] != 1 {
+		t.Fatalf("expected [2 1], got %v", got)
+	}
+
+	// Typos are tolerated
+	if got := titles("glang"); len(got) != 2 {
+		t.Fatalf("expected typo-tolerant matches, got %v", got)
+	}
+
+	// Likes move entries up
+	index.AddLikes(1, 100)
+	if got := titles("golang"); got[0] != 1 {
+		t.Fatalf("expected the most liked blog first, got %v", got)
+	}
+
+	// Authors aggregate likes and disappear with their last blog
+	authors := index.Suggest("sit", 5).Authors
+	if len(authors) != 1 || authors[0].Likes != 10 {
+		t.Fatalf("unexpected authors %+v", authors)
+	}
+	index.Remove(2)
+	index.Remove(3)
+	if authors := index.Suggest("sit", 5).Authors; len(authors) != 0 {
+		t.Fatalf("expected no authors, got %+v", authors)
+	}
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"testing"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/imaging"
+)
+
+// exifJPEG encodes a JPEG of the given size whose EXIF block holds the
+// orientation and a GPS position.
+func exifJPEG(t *testing.T, width, height, orientation int) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, width, height)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Big-endian TIFF header and an IFD with the orientation (0x0112, SHORT)
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1, 0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, byte(orientation), 0, 0, 0, 0, 0, 0}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	payload = append(payload, "GPS 52.3676N 4.9041E"...)
+	app1 := append([]byte{0xFF, 0xE1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}, payload...)
+
+	// The APP1 segment goes right after the SOI marker
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), app1...), data[2:]...)
+}
+
+func TestProcessThumbnail(t *testing.T) {
+	// The EXIF orientation is applied, then the metadata is dropped
+	result, err := imaging.Process(exifJPEG(t, 1000, 500, 6))
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(result.Original))
+	if err != nil || cfg.Width != 500 || cfg.Height != 1000 {
+		t.Fatalf("expected an upright 500x1000 image, got %+v, %v", cfg, err)
+	}
+	if bytes.Contains(result.Original, []byte("Exif")) || bytes.Contains(result.Original, []byte("GPS")) {
+		t.Fatal("the metadata was kept")
+	}
+
+	// A variant per width keeps the aspect ratio and is never upscaled
+	for _, width := range imaging.Widths {
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(result.Variants[width]))
+		if want := min(width, 500); err != nil || cfg.Width != want || cfg.Height != 2*want {
+			t.Fatalf("unexpected %dw variant: %+v, %v", width, cfg, err)
+		}
+	}
+
+	// The variants are listed like a srcset
+	srcSet := imaging.SrcSet("abc.jpg", func(name string) string { return "/uploads/" + name })
+	if len(srcSet) != len(imaging.Widths) || srcSet["320w"] != "/uploads/abc_320.jpg" {
+		t.Fatalf("unexpected srcset %v", srcSet)
+	}
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/config"
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracing.Use(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	defer tracing.Use(noop.NewTracerProvider())
+
+	s := NewServer(t)
+	_, token := s.NewUser("budi")
+
+	// Storage operations are children of the request that uploads
+	exporter.Reset()
+	s.PostBlog(token, "Judul", "Content")
+	post := findSpan(t, exporter.GetSpans(), "POST /api/blogs/")
+	var puts int
+	for _, span := range childSpans(exporter.GetSpans(), post) {
+		if span.Name == "storage.put" {
+			puts++
+		}
+	}
+	if puts == 0 {
+		t.Fatal("expected storage.put spans under the upload request")
+	}
+
+	// The trace of the caller continues, with the queries as children
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/api/blogs", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	s.Serve(req).Expect(http.StatusOK)
+
+	server := findSpan(t, exporter.GetSpans(), "GET /api/blogs")
+	if server.SpanKind != trace.SpanKindServer || server.Parent.SpanID().String() != "00f067aa0ba902b7" || server.SpanContext.TraceID().String() != traceID {
+		t.Fatalf("the request span does not continue the caller's trace: %+v", server)
+	}
+	queries := childSpans(exporter.GetSpans(), server)
+	if len(queries) == 0 {
+		t.Fatal("expected the queries of the request as child spans")
+	}
+	for _, span := range queries {
+		if !strings.HasPrefix(span.Name, "gorm.") || attr(span, "db.query.text") == "" || attr(span, "db.system") != "sqlite" {
+			t.Fatalf("unexpected query span: %s %v", span.Name, span.Attributes)
+		}
+	}
+
+	// The SQL is recorded without the values bound to it
+	exporter.Reset()
+	s.Login("budi@example.com", "secret123")
+	for _, span := range exporter.GetSpans() {
+		if query := attr(span, "db.query.text"); strings.Contains(query, "budi@example.com") {
+			t.Fatalf("a query span leaks the email: %s", query)
+		}
+	}
+
+	// Server errors fail the span, and a new trace starts without traceparent
+	exporter.Reset()
+	s.Engine.GET("/panic", func(c *gin.Context) { panic("boom") })
+	s.Get("/panic", "").Expect(http.StatusInternalServerError)
+	failed := findSpan(t, exporter.GetSpans(), "GET /panic")
+	if failed.Status.Code != codes.Error || failed.Parent.IsValid() {
+		t.Fatalf("unexpected span of a failed request: %+v", failed)
+	}
+}
+
+func TestTracingExporters(t *testing.T) {
+	var buf bytes.Buffer
+	exporter, err := tracing.NewExporter(context.Background(), config.TracingConfig{Exporter: "stdout"}, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider, err := tracing.NewProvider(config.TracingConfig{ServiceName: "blog-test", SampleRatio: 1}, exporter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, span := provider.Tracer("test").Start(context.Background(), "work")
+	span.End()
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"Name": "work"`) || !strings.Contains(buf.String(), "blog-test") {
+		t.Fatalf("the span was not written to stdout: %s", buf.String())
+	}
+
+	if _, err := tracing.NewExporter(context.Background(), config.TracingConfig{Exporter: "otlp", OTLPEndpoint: "http://localhost:4318"}, nil); err != nil {
+		t.Fatalf("creating the OTLP exporter: %v", err)
+	}
+
+	t.Setenv("SECRET", strings.Repeat("s", config.MinSecretLength))
+	t.Setenv("DNS", "blog.db")
+	t.Setenv("TRACING_EXPORTER", "otlp")
+	t.Setenv("TRACING_SAMPLE_RATIO", "2")
+	_, err = config.Load(filepath.Join(t.TempDir(), "missing.env"))
+	if err == nil || !strings.Contains(err.Error(), "TRACING_OTLP_ENDPOINT") || !strings.Contains(err.Error(), "TRACING_SAMPLE_RATIO") {
+		t.Fatalf("expected tracing validation errors, got %v", err)
+	}
+}
+
+// findSpan returns the span named name.
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("no span %q among %d", name, len(spans))
+	return tracetest.SpanStub{}
+}
+
+// childSpans returns the spans whose parent is parent.
+func childSpans(spans tracetest.SpanStubs, parent tracetest.SpanStub) []tracetest.SpanStub {
+	var children []tracetest.SpanStub
+	for _, span := range spans {
+		if span.Parent.SpanID() == parent.SpanContext.SpanID() {
+			children = append(children, span)
+		}
+	}
+	return children
+}
+
+// attr returns the value of the attribute key of span.
+func attr(span tracetest.SpanStub, key string) string {
+	for _, kv := range span.Attributes {
+		if string(kv.Key) == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
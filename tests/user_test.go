@@ -1,14 +1,80 @@
 package tests
 
 import (
-	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
 	"testing"
 )
 
 func TestCreatUser(t *testing.T) {
-	// Set gin to test mode
-	gin.SetMode(gin.TestMode)
+	s := NewServer(t)
 
-	// Recreate a clean database for testing
-	DatabaseRefresh()
+	var user struct {
+		ID    uint   `json:"id"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	w := s.Signup("Budi", "budi@example.com", "secret123").Expect(http.StatusOK)
+	w.Data(&user)
+	if user.ID == 0 || user.Name != "Budi" || user.Email != "budi@example.com" {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+	if strings.Contains(w.Body.String(), "password") {
+		t.Fatalf("the password leaked into the response: %s", w.Body)
+	}
+
+	// The email is unique
+	s.Signup("Budi Lagi", "budi@example.com", "secret123").Expect(http.StatusUnprocessableEntity)
+
+	// Invalid fields and malformed bodies are refused
+	s.Signup("B", "not-an-email", "123").Expect(http.StatusUnprocessableEntity)
+	s.JSON(http.MethodPost, "/api/signup", "", `{"name": `).Expect(http.StatusBadRequest)
+}
+
+func TestLogin(t *testing.T) {
+	s := NewServer(t)
+	s.Signup("Budi", "budi@example.com", "secret123").Expect(http.StatusOK)
+
+	if token := s.Login("budi@example.com", "secret123"); strings.Count(token, ".") != 2 {
+		t.Fatalf("expected a JWT, got %q", token)
+	}
+
+	// A wrong password and an unknown email get the same answer
+	wrong := s.JSON(http.MethodPost, "/api/login", "", map[string]string{"email": "budi@example.com", "password": "wrong-password"})
+	unknown := s.JSON(http.MethodPost, "/api/login", "", map[string]string{"email": "siti@example.com", "password": "secret123"})
+	if wrong.Code != http.StatusUnauthorized || wrong.Body.String() != unknown.Body.String() {
+		t.Fatalf("expected identical 401s, got %d %s and %d %s", wrong.Code, wrong.Body, unknown.Code, unknown.Body)
+	}
+
+	s.JSON(http.MethodPost, "/api/login", "", map[string]string{"email": "budi@example.com"}).Expect(http.StatusUnprocessableEntity)
+}
+
+func TestUserProfile(t *testing.T) {
+	s := NewServer(t)
+	_, token := s.NewUser("budi")
+	s.NewUser("siti")
+
+	// Protected routes need a valid token
+	s.Get("/api/users/", "").Expect(http.StatusUnauthorized)
+	s.Get("/api/users/", "not-a-token").Expect(http.StatusUnauthorized)
+
+	var profile struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	s.Get("/api/users/", token).Expect(http.StatusOK).Data(&profile)
+	if profile.Name != "budi" || profile.Email != "budi@example.com" {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+
+	update := map[string]string{"name": "Budi Santoso", "email": "siti@example.com", "tanggal_lahir": "1999-12-31", "biografi": "Baru"}
+	s.JSON(http.MethodPut, "/api/users/update", token, update).Expect(http.StatusUnprocessableEntity)
+
+	update["email"] = "budi.santoso@example.com"
+	s.JSON(http.MethodPut, "/api/users/update", token, update).Expect(http.StatusOK)
+	s.Get("/api/users/", token).Expect(http.StatusOK).Data(&profile)
+	if profile.Name != "Budi Santoso" || profile.Email != "budi.santoso@example.com" {
+		t.Fatalf("profile not updated: %+v", profile)
+	}
+	s.Login("budi.santoso@example.com", "secret123")
 }
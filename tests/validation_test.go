@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Tokenzrey/FPPBKKGOLANG/internal/helpers"
+)
+
+func TestValidationErrors(t *testing.T) {
+	s := NewServer(t)
+	_, token := s.NewUser("budi")
+
+	send := func(method, path, token, lang, payload string) helpers.APIResponse {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", lang)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := s.Serve(req).Expect(http.StatusUnprocessableEntity)
+
+		var body helpers.APIResponse
+		w.Decode(&body)
+		if body.Code != helpers.CodeValidation {
+			t.Fatalf("expected %s, got %s", helpers.CodeValidation, w.Body)
+		}
+		return body
+	}
+
+	// Every invalid field is reported under its JSON name, in English by default
+	invalid := `{"name": "B", "email": "not-an-email", "password": "123", "tanggal_lahir": "02-01-2000"}`
+	body := send(http.MethodPost, "/api/signup", "", "", invalid)
+	want := map[string][]string{
+		"name":          {"name must be at least 2 characters in length"},
+		"email":         {"email must be a valid email address"},
+		"password":      {"password must be at least 6 characters in length"},
+		"tanggal_lahir": {"tanggal_lahir must be a date such as 2006-01-02"},
+		"biografi":      {"biografi is a required field"},
+	}
+	if body.Message != "Validation failed" || !reflect.DeepEqual(body.Errors, want) {
+		t.Fatalf("unexpected errors: %s %v", body.Message, body.Errors)
+	}
+
+	// Indonesian when the client prefers it
+	for _, lang := range []string{"id", "id-ID,id;q=0.9,en;q=0.8", "fr-FR, id;q=0.5"} {
+		body = send(http.MethodPost, "/api/signup", "", lang, invalid)
+		if body.Message != "Validasi gagal" || body.Errors["biografi"][0] != "biografi wajib diisi" {
+			t.Fatalf("%s: unexpected errors: %s %v", lang, body.Message, body.Errors)
+		}
+	}
+	if body = send(http.MethodPost, "/api/signup", "", "en;q=0.9, id;q=0.5", invalid); body.Message != "Validation failed" {
+		t.Fatalf("expected English, got %q", body.Message)
+	}
+
+	// A registered email is a field error, from the validator or the service
+	signup := `{"name": "Budi", "email": "budi@example.com", "password": "secret123", "tanggal_lahir": "2000-01-02", "biografi": "Halo"}`
+	body = send(http.MethodPost, "/api/signup", "", "", signup)
+	if !reflect.DeepEqual(body.Errors, map[string][]string{"email": {"email is already registered"}}) {
+		t.Fatalf("unexpected errors: %v", body.Errors)
+	}
+	s.NewUser("siti")
+	update := `{"name": "Budi", "email": "siti@example.com", "tanggal_lahir": "2000-01-02"}`
+	body = send(http.MethodPut, "/api/users/update", token, "id", update)
+	if !reflect.DeepEqual(body.Errors, map[string][]string{"email": {"email sudah terdaftar"}}) {
+		t.Fatalf("unexpected errors: %v", body.Errors)
+	}
+
+	// Comments and likes report their fields too
+	body = send(http.MethodPost, "/comment", token, "", `{"comment": "hai"}`)
+	if len(body.Errors["comment"]) != 1 || len(body.Errors["blog_id"]) != 1 {
+		t.Fatalf("unexpected errors: %v", body.Errors)
+	}
+}